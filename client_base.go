@@ -8,12 +8,13 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"strconv"
-	// "github.com/hashicorp/go-retryablehttp"
+	"strings"
+	"time"
 )
 
 const (
@@ -21,38 +22,40 @@ const (
 	BaseURL    = "https://qvapay.com/api"
 )
 
-type IQvaPay interface {
+// AppAPI are the calls of an app, authenticated with its credentials.
+type AppAPI interface {
 	// GetInfo returns the corresponding object info on fetch call, or an error.
 	GetInfo(ctx context.Context) (*AppInfoResponse, error)
 	// CreateInvoice ...
 	CreateInvoice(
 		ctx context.Context,
-		amount float64,
+		amount Amount,
 		description string,
 		remoteID string,
 	) (*InvoiceResponse, error)
-	// GetTransactions ...
+	// GetTransactions returns a page of transactions, Page, Status and
+	// RemoteID are sent to the API and every filter of query is applied
+	// to the page returned.
 	GetTransactions(ctx context.Context, query APIQueryParams) (*TransactionsResponse, error)
 	// GetTransaction ...
 	GetTransaction(ctx context.Context, id string) (*TransactionReponse, error)
 	// GetBalance ...
-	GetBalance(ctx context.Context) (float64, error)
+	GetBalance(ctx context.Context) (Amount, error)
+}
 
+// P2PAPI are the public calls of the P2P market, they need no credentials.
+type P2PAPI interface {
 	// qvapay v2
 
 	// Offers ...
-	Offers(ctx context.Context, query QueryParams) (map[string]any, error)
+	Offers(ctx context.Context, query QueryParams) (*OffersPage, error)
 }
 
-// QvaPayFactory it`s a constructor factory method
-func QvaPayFactory(apiType string, opts Options) (IQvaPay, error) {
-	switch apiType {
-	case "qvapay":
-		return NewQvaPay(opts), nil
-	case "app":
-		return NewPaymentAppClient(opts), nil
-	}
-	return nil, errors.New("error: Bad Payment method type passed")
+// IQvaPay is the full client of an app, every capability its credentials
+// allow.
+type IQvaPay interface {
+	AppAPI
+	P2PAPI
 }
 
 type client struct {
@@ -61,8 +64,98 @@ type client struct {
 	debug      io.Writer
 	appID      string
 	appSecret  string
+	retry      *RetryPolicy
+	limiter    *RateLimiter
+	redactor   *Redactor
+	credsMode  CredentialsMode
+	onError    func(error)
+	logger     *slog.Logger
+	tracer     Tracer
+	metrics    Metrics
+	kind       string
+	doer       Doer
+}
+
+// CredentialsMode defines where the app credentials are sent.
+type CredentialsMode int
+
+const (
+	// CredentialsInQuery sends app_id and app_secret in the query string,
+	// it's the default and the only mode supported by every v1 route.
+	CredentialsInQuery CredentialsMode = iota
+	// CredentialsInHeader sends the app-id and app-secret headers.
+	CredentialsInHeader
+	// CredentialsInBody sends app_id and app_secret in a JSON body.
+	CredentialsInBody
+)
+
+// appRoutes are the routes that require the app credentials.
+var appRoutes = map[string]bool{
+	RouteInfo:    true,
+	RouteInvoice: true,
+	RouteTxs:     true,
+	RouteTx:      true,
+	RouteBalance: true,
+}
+
+// credentials returns the query values for an app route, with the app
+// credentials set when they travel in the query string.
+func (c *client) credentials() url.Values {
+	v := url.Values{}
+	if c.credsMode == CredentialsInQuery {
+		v.Set("app_id", c.appID)
+		v.Add("app_secret", c.appSecret)
+	}
+	return v
+}
+
+// authenticate adds the app credentials to the headers or the body of a
+// call to an app route, according to the credentials mode.
+func (c *client) authenticate(route string, header http.Header, data []byte) ([]byte, error) {
+	if !appRoutes[route] {
+		return data, nil
+	}
+	switch c.credsMode {
+	case CredentialsInHeader:
+		header.Set("app-id", c.appID)
+		header.Set("app-secret", c.appSecret)
+	case CredentialsInBody:
+		body := map[string]any{}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &body); err != nil {
+				return nil, fmt.Errorf("failed to add credentials to body: %v", err)
+			}
+		}
+		body["app_id"] = c.appID
+		body["app_secret"] = c.appSecret
+		return json.Marshal(body)
+	}
+	return data, nil
 }
 
+// newClient returns a client with the settings shared by every kind of
+// client, each one with its own HTTP client unless the caller supplied one.
+func newClient(opts Options, kind string) *client {
+	c := &client{
+		url:        opts.BaseURL,
+		httpClient: newHTTPClient(opts),
+		debug:      opts.Debug,
+		retry:      opts.Retry,
+		limiter:    opts.RateLimiter,
+		redactor:   NewRedactor(opts.RedactKeys...),
+		credsMode:  opts.Credentials,
+		onError:    opts.ErrorHandler,
+		logger:     opts.Logger,
+		tracer:     opts.Tracer,
+		metrics:    opts.Metrics,
+		kind:       kind,
+	}
+	c.doer = chain(DoerFunc(c.send), opts.Middlewares)
+	return c
+}
+
+// TransPortAuthBasic is a RoundTripper that sets a bearer token, prefer the
+// BearerAuth middleware.
 type TransPortAuthBasic struct {
 	Transport http.RoundTripper
 	Token     string
@@ -73,57 +166,187 @@ func (t TransPortAuthBasic) RoundTrip(r *http.Request) (*http.Response, error) {
 	return t.Transport.RoundTrip(r)
 }
 
-// dumpResponse writes the raw response data to the debug output, if set, or
-// standard error otherwise.
-func (c *client) dumpResponse(resp *http.Response) {
-	// ignore errors dumping response - no recovery from this
+// dumpResponse writes the raw response data to the debug output.
+func (c *client) dumpResponse(resp *http.Response) error {
 	responseDump, err := httputil.DumpResponse(resp, true)
 	if err != nil {
-		log.Fatalf("dumpResponse: " + err.Error())
+		return fmt.Errorf("error dumping HTTP response: %v", err)
+	}
+	if _, err := fmt.Fprintf(c.debug, "%s\n\n", c.redactor.Redact(string(responseDump))); err != nil {
+		return fmt.Errorf("error writing HTTP response dump: %v", err)
+	}
+	return nil
+}
+
+// reportError sends the errors that don't make a call fail (debug output,
+// body close) to the error handler, or to the logger or the debug output if
+// there is no handler.
+func (c *client) reportError(err error) {
+	if err == nil {
+		return
+	}
+	if c.onError != nil {
+		c.onError(err)
+		return
+	}
+	if c.logger != nil {
+		c.logger.Warn("qvapay: "+err.Error(), slog.String("error_class", "internal"))
+		return
+	}
+	if c.debug != nil {
+		fmt.Fprintf(c.debug, "qvapay: %v\n", err)
 	}
-	fmt.Fprintln(c.debug, string(responseDump))
-	fmt.Fprintln(c.debug)
 }
 
-// apiCall define how you can make a call to API
+// apiCall define how you can make a call to API, every attempt waits for the
+// client rate limiter and idempotent routes are replayed according to the
+// client retry policy. decode, if any, reads a 200 response before the call
+// is reported, so a body that can't be decoded counts as a failed call.
 func (c *client) apiCall(
 	ctx context.Context,
+	route string,
 	method string,
 	URL string,
+	extraHeader http.Header,
 	data []byte,
+	decode func(response string) error,
 ) (statusCode int, response string, err error) {
+	header := extraHeader.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	if data, err = c.authenticate(route, header, data); err != nil {
+		return 0, "", err
+	}
+	if c.tracer != nil {
+		c.tracer.Inject(ctx, header)
+	}
+	info := callInfoFrom(ctx)
+	maxAttempts := c.retry.attempts(route)
+	attempt := 1
+	start := time.Now()
+	if c.metrics != nil {
+		c.metrics.CallStarted(c.kind, route)
+	}
+	defer func() {
+		if c.metrics != nil {
+			c.metrics.CallFinished(c.kind, route, statusCode, errorClass(statusCode, err), time.Since(start))
+		}
+		spanFromContext(ctx).setAttributes(
+			Attribute{Key: AttrStatusCode, Value: statusCode},
+			Attribute{Key: AttrAttempts, Value: attempt},
+		)
+		c.logCall(ctx, callRecord{
+			route:    route,
+			method:   method,
+			url:      URL,
+			status:   statusCode,
+			attempts: attempt,
+			latency:  time.Since(start),
+			request:  data,
+			response: response,
+			err:      err,
+		})
+	}()
+	for ; ; attempt++ {
+		if err = c.limiter.Wait(ctx); err != nil {
+			break
+		}
+		var respHeader http.Header
+		statusCode, response, respHeader, err = c.doRequest(ctx, method, URL, header, data)
+		if info != nil {
+			info.Attempts = attempt
+			info.StatusCode = statusCode
+		}
+		wait := retryAfter(respHeader, time.Now())
+		if wait > 0 {
+			c.limiter.PauseUntil(time.Now().Add(wait))
+		}
+		if attempt >= maxAttempts || !c.retry.shouldRetry(ctx, statusCode, err) {
+			break
+		}
+		if d := c.retry.delay(attempt); d > wait {
+			wait = d
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return statusCode, response, &RetryError{Attempts: attempt, Err: err}
+		}
+		if c.metrics != nil {
+			c.metrics.CallRetried(c.kind, route)
+		}
+	}
+	if err == nil && statusCode == http.StatusOK && decode != nil {
+		err = decode(response)
+	}
+	if err != nil && attempt > 1 {
+		err = &RetryError{Attempts: attempt, Err: err}
+	}
+	return statusCode, response, err
+}
+
+// decodeJSON returns a decode func of apiCall that reads a JSON response
+// into v.
+func (c *client) decodeJSON(v any) func(response string) error {
+	return func(response string) error {
+		if err := json.NewDecoder(strings.NewReader(response)).Decode(v); err != nil {
+			return c.decodeError(response, err)
+		}
+		return nil
+	}
+}
+
+// doRequest makes a single HTTP attempt.
+func (c *client) doRequest(
+	ctx context.Context,
+	method string,
+	URL string,
+	header http.Header,
+	data []byte,
+) (statusCode int, response string, respHeader http.Header, err error) {
 
 	req, err := http.NewRequest(method, URL, bytes.NewBuffer(data))
 	if err != nil {
-		return 0, "", fmt.Errorf("failed to create HTTP request: %v", err)
+		return 0, "", nil, fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
 	}
 	req.Header.Add("content-type", "application/json")
 	req.Header.Add("User-Agent", "qvapay-go")
 	if c.debug != nil {
 		requestDump, err := httputil.DumpRequestOut(req, true)
 		if err != nil {
-			return 0, "", fmt.Errorf("error dumping HTTP request: %v", err)
+			return 0, "", nil, fmt.Errorf("error dumping HTTP request: %v", err)
+		}
+		if _, err := fmt.Fprintf(c.debug, "%s\n\n", c.redactor.Redact(string(requestDump))); err != nil {
+			c.reportError(fmt.Errorf("error writing HTTP request dump: %v", err))
 		}
-		fmt.Fprintln(c.debug, string(requestDump))
-		fmt.Fprintln(c.debug)
 	}
 	req = req.WithContext(ctx)
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, "", fmt.Errorf("HTTP request failed with: %v", err)
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = c.redactor.Redact(urlErr.URL)
+		}
+		return 0, "", nil, fmt.Errorf("HTTP request failed with: %w", err)
 	}
-	defer DrainBody(resp.Body)
+	defer func() {
+		c.reportError(DrainBody(resp.Body))
+	}()
 	if c.debug != nil {
-		c.dumpResponse(resp)
+		c.reportError(c.dumpResponse(resp))
 	}
 	res, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return resp.StatusCode, "", fmt.Errorf("HTTP request failed: %v", err)
+		return resp.StatusCode, "", resp.Header, fmt.Errorf("HTTP request failed: %w", err)
 	}
-	return resp.StatusCode, string(res), nil
+	return resp.StatusCode, string(res), resp.Header, nil
 }
 
-func DrainBody(respBody io.ReadCloser) {
+// DrainBody reads what is left of respBody and closes it, returning the close
+// error if any.
+func DrainBody(respBody io.ReadCloser) error {
 	// Callers should close resp.Body when done reading from it.
 	// If resp.Body is not closed, the Client's underlying RoundTripper
 	// (typically Transport) may not be able to re-use a persistent TCP
@@ -133,14 +356,12 @@ func DrainBody(respBody io.ReadCloser) {
 		// Without this closing connection would disallow re-using
 		// the same connection for future uses.
 		//  - http://stackoverflow.com/a/17961593/4465767
-		defer func(respBody io.ReadCloser) {
-			err := respBody.Close()
-			if err != nil {
-				log.Fatal(err)
-			}
-		}(respBody)
 		_, _ = io.Copy(ioutil.Discard, respBody)
+		if err := respBody.Close(); err != nil {
+			return fmt.Errorf("error closing response body: %v", err)
+		}
 	}
+	return nil
 }
 
 // ParseUrlQueryParams ...
@@ -163,6 +384,10 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("API Error, %+v", e.ErrorMessage)
 }
 
+// HandleAPIErrorResponse decodes an API error body.
+//
+// Deprecated: endpoints return a *StatusError, which carries the decoded
+// message along with the status code.
 func HandleAPIErrorResponse(response string) error {
 	var err error
 	errorApi := &APIError{}
@@ -1,13 +1,9 @@
 package qvapay
 
-import (
-	"crypto/tls"
-	"net/http"
-	"os"
-	"time"
-)
+import "os"
 
-// PaymentAppClient is an interface that implements https://qvapay.com/api
+// PaymentAppClient is the client of an app, authenticated with its
+// credentials, it implements every call of https://qvapay.com/api
 type PaymentAppClient interface {
 	IQvaPay
 }
@@ -15,22 +11,9 @@ type PaymentAppClient interface {
 func NewPaymentAppClient(
 	opts Options,
 ) PaymentAppClient {
-	tr := &http.Transport{
-		Proxy:                 http.ProxyFromEnvironment,
-		MaxIdleConns:          256,
-		MaxIdleConnsPerHost:   256,
-		IdleConnTimeout:       60 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-		DisableCompression:    true,
-	}
-	c := &client{
-		appID:      opts.AppID,
-		appSecret:  opts.SecretID,
-		url:        opts.BaseURL,
-		httpClient: opts.HttpClient,
-		debug:      opts.Debug,
-	}
+	c := newClient(opts, KindApp)
+	c.appID = opts.AppID
+	c.appSecret = opts.SecretID
 
 	if opts.AppID == "" {
 		c.appID = os.Getenv("APP_ID")
@@ -41,17 +24,6 @@ func NewPaymentAppClient(
 	if opts.BaseURL == "" {
 		c.url = BaseURL
 	}
-	if opts.HttpClient != nil {
-		c.httpClient = opts.HttpClient
-	} else {
-		c.httpClient = http.DefaultClient
-	}
-	if opts.SkipVerify {
-		// #nosec
-		tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-		c.httpClient.Transport = tr
-	}
-	c.httpClient.Transport = tr
 
 	return c
 }
@@ -1,9 +1,8 @@
 package qvapay
 
 import (
-	"net/http"
+	"context"
 	"os"
-	// "github.com/hashicorp/go-retryablehttp"
 )
 
 // QueryParams ...
@@ -11,30 +10,31 @@ type QueryParams struct {
 	Page int
 }
 
-// QvaClient is an interface that implements some method for Qvapay
+// QvaClient is the client of the public API, without credentials it can
+// only read the P2P offers.
 type QvaClient interface {
-	IQvaPay
+	P2PAPI
 }
 
 // NewQvaPay constructor
 func NewQvaPay(
 	opts Options,
 ) QvaClient {
-
-	c := &client{
-		url:        opts.BaseURL,
-		httpClient: opts.HttpClient,
-		debug:      opts.Debug,
-	}
+	c := newClient(opts, KindQvaPay)
 
 	if opts.BaseURL == "" {
 		c.url = os.Getenv("QVAPAY_API")
 	}
-	if opts.HttpClient != nil {
-		c.httpClient = opts.HttpClient
-	} else {
-		c.httpClient = http.DefaultClient
-	}
 
-	return c
+	return &publicClient{c: c}
+}
+
+// publicClient only exposes the calls that need no credentials, so that a
+// QvaClient can't be asserted to an AppAPI.
+type publicClient struct {
+	c *client
+}
+
+func (p *publicClient) Offers(ctx context.Context, query QueryParams) (*OffersPage, error) {
+	return p.c.Offers(ctx, query)
 }
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/kenriortega/qvapay-go/qvapaytest"
+)
+
+// actions are the status changes the admin can apply to a transaction.
+var actions = map[string]qvapay.TransactionStatus{
+	"pay":    qvapay.StatusPaid,
+	"cancel": qvapay.StatusCancelled,
+	"expire": qvapay.StatusExpired,
+}
+
+// admin serves the admin page and API, to see the apps and pay or cancel
+// their invoices.
+type admin struct {
+	engine *qvapaytest.Engine
+}
+
+func (a *admin) routes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/{$}", a.index)
+	mux.HandleFunc("POST /admin/transactions/{id}/{action}", a.submit)
+	mux.HandleFunc("GET /admin/api/apps", a.apps)
+	mux.HandleFunc("GET /admin/api/apps/{app}/transactions", a.transactions)
+	mux.HandleFunc("POST /admin/api/transactions/{id}/{action}", a.apply)
+	// the invoice URL returned by create_invoice
+	mux.HandleFunc("GET /pay/{id}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/admin/?id="+url.QueryEscape(r.PathValue("id")), http.StatusFound)
+	})
+}
+
+type appView struct {
+	qvapaytest.App
+	Transactions []qvapay.Transaction
+}
+
+// view returns every app with its transactions, newest first, only the
+// transaction id when it's not empty.
+func (a *admin) view(id string) []appView {
+	var views []appView
+	for _, app := range a.engine.Apps() {
+		v := appView{App: app}
+		for _, tx := range a.appTransactions(app.ID) {
+			if id == "" || tx.ID == id {
+				v.Transactions = append(v.Transactions, tx)
+			}
+		}
+		views = append(views, v)
+	}
+	return views
+}
+
+func (a *admin) appTransactions(appID string) []qvapay.Transaction {
+	var txs []qvapay.Transaction
+	for page := 1; ; page++ {
+		res, err := a.engine.Transactions(appID, qvapay.APIQueryParams{Page: page})
+		if err != nil {
+			return txs
+		}
+		txs = append(txs, res.Data...)
+		if page >= res.LastPage {
+			return txs
+		}
+	}
+}
+
+// setStatus applies an action, the transaction is returned along with the
+// error of the app callback.
+func (a *admin) setStatus(r *http.Request) (qvapay.Transaction, int, error) {
+	status, ok := actions[r.PathValue("action")]
+	if !ok {
+		return qvapay.Transaction{}, http.StatusNotFound, errors.New("unknown action " + r.PathValue("action"))
+	}
+	tx, err := a.engine.SetStatus(r.Context(), r.PathValue("id"), status)
+	var statusErr *qvapay.StatusError
+	switch {
+	case err == nil:
+		return tx, http.StatusOK, nil
+	case errors.As(err, &statusErr):
+		return tx, statusErr.StatusCode, err
+	case errors.Is(err, qvapay.ErrInvalidTransition):
+		return tx, http.StatusConflict, err
+	}
+	// the status changed but the callback failed
+	log.Printf("qvapay-mock: %v", err)
+	return tx, http.StatusOK, err
+}
+
+func (a *admin) index(w http.ResponseWriter, r *http.Request) {
+	data := map[string]any{
+		"Apps":   a.view(r.URL.Query().Get("id")),
+		"Error":  r.URL.Query().Get("error"),
+		"Filter": r.URL.Query().Get("id"),
+		// the order of the buttons
+		"Actions": []string{"pay", "cancel", "expire"},
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := indexPage.Execute(w, data); err != nil {
+		log.Printf("qvapay-mock: admin page: %v", err)
+	}
+}
+
+func (a *admin) submit(w http.ResponseWriter, r *http.Request) {
+	back := url.Values{}
+	if r.FormValue("filter") != "" {
+		back.Set("id", r.FormValue("filter"))
+	}
+	if _, _, err := a.setStatus(r); err != nil {
+		back.Set("error", err.Error())
+	}
+	http.Redirect(w, r, "/admin/?"+back.Encode(), http.StatusSeeOther)
+}
+
+func (a *admin) apps(w http.ResponseWriter, _ *http.Request) {
+	apps := a.engine.Apps()
+	for i := range apps {
+		apps[i].Secret = ""
+	}
+	writeJSON(w, http.StatusOK, apps)
+}
+
+func (a *admin) transactions(w http.ResponseWriter, r *http.Request) {
+	if _, ok := a.engine.App(r.PathValue("app")); !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "app not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, a.appTransactions(r.PathValue("app")))
+}
+
+func (a *admin) apply(w http.ResponseWriter, r *http.Request) {
+	tx, status, err := a.setStatus(r)
+	res := map[string]any{}
+	if tx.ID != "" {
+		res["transaction"] = tx
+	}
+	if err != nil {
+		res["error"] = err.Error()
+	}
+	writeJSON(w, status, res)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+var indexPage = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>qvapay-mock</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: .3em .6em; text-align: left; }
+.error { color: #b00; }
+form { display: inline; }
+</style>
+</head>
+<body>
+<h1>qvapay-mock</h1>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+{{if .Filter}}<p>Transaction {{.Filter}} &middot; <a href="/admin/">all transactions</a></p>{{end}}
+{{range .Apps}}
+<h2>{{.Name}} <small>{{.ID}}</small></h2>
+<p>Balance: {{.Balance}} &middot; Callback: {{if .Callback}}{{.Callback}}{{else}}none{{end}}</p>
+<table>
+<tr><th>uuid</th><th>remote_id</th><th>description</th><th>amount</th><th>status</th><th>created_at</th><th></th></tr>
+{{range .Transactions}}
+<tr>
+<td>{{.ID}}</td><td>{{.RemoteID}}</td><td>{{.Description}}</td><td>{{.Amount}}</td><td>{{.Status}}</td>
+<td>{{.CreatedAt.Format "2006-01-02 15:04:05"}}</td>
+<td>{{if not .Status.IsFinal}}{{$id := .ID}}
+{{range $action := $.Actions}}<form method="post" action="/admin/transactions/{{$id}}/{{$action}}">
+<input type="hidden" name="filter" value="{{$.Filter}}"><button>{{$action}}</button>
+</form>{{end}}
+{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,69 @@
+// Command qvapay-mock runs a fake QvaPay for development, without real money
+// nor network. It serves the API routes called by the qvapay clients, sends
+// the app callbacks and has an admin page at /admin/ to pay or cancel the
+// invoices.
+//
+//	go run ./cmd/qvapay-mock -addr :8080 -seed cmd/qvapay-mock/seed.example.yaml
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/kenriortega/qvapay-go/qvapaytest"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	seedPath := flag.String("seed", "", "YAML or JSON file with the apps, transactions and P2P offers")
+	publicURL := flag.String("url", "", "base URL of the links in the responses (default http://localhost<addr>)")
+	flag.Parse()
+
+	baseURL := *publicURL
+	if baseURL == "" {
+		baseURL = "http://localhost" + *addr
+		if !strings.HasPrefix(*addr, ":") {
+			baseURL = "http://" + *addr
+		}
+	}
+	engine := qvapaytest.NewEngine(qvapaytest.EngineOptions{BaseURL: strings.TrimSuffix(baseURL, "/")})
+	if *seedPath != "" {
+		s, err := loadSeed(*seedPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := s.apply(engine); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           newMux(engine),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("qvapay-mock listening on %s, admin page at %s/admin/", *addr, baseURL)
+	log.Fatal(srv.ListenAndServe())
+}
+
+// newMux serves the API routes and the admin ones.
+func newMux(engine *qvapaytest.Engine) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", qvapaytest.NewHandler(engine))
+	(&admin{engine: engine}).routes(mux)
+	return logRequests(mux)
+}
+
+// logRequests logs every request, with the app secrets redacted.
+func logRequests(next http.Handler) http.Handler {
+	redactor := qvapay.NewRedactor()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		log.Printf("%s %s %s", r.Method, redactor.Redact(r.URL.RequestURI()), time.Since(start))
+	})
+}
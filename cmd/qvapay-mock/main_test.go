@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/kenriortega/qvapay-go/qvapaytest"
+	"github.com/kenriortega/qvapay-go/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMock(t *testing.T, seedPath string) (*httptest.Server, *qvapaytest.Engine) {
+	s, err := loadSeed(seedPath)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	engine := qvapaytest.NewEngine(qvapaytest.EngineOptions{})
+	if err := s.apply(engine); err != nil {
+		t.Fatalf(err.Error())
+	}
+	srv := httptest.NewServer(newMux(engine))
+	t.Cleanup(srv.Close)
+	return srv, engine
+}
+
+func Test_Example_Seed(t *testing.T) {
+	srv, _ := newMock(t, "seed.example.yaml")
+	client := qvapay.NewPaymentAppClient(qvapay.Options{BaseURL: srv.URL, AppID: "demo-app", SecretID: "demo-secret"})
+
+	balance, err := client.GetBalance(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, qvapay.MustParseAmount("100"), balance)
+
+	tx, err := client.GetTransaction(context.Background(), "6507ee0d-db6c-4aa9-b59a-75dc7f6eab52")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, qvapay.StatusPaid, tx.Status)
+	assert.Equal(t, 2021, tx.CreatedAt.Year())
+
+	offers, err := qvapay.NewQvaPay(qvapay.Options{BaseURL: srv.URL}).Offers(context.Background(), qvapay.QueryParams{})
+	assert.NoError(t, err)
+	assert.Len(t, offers.Data, 1)
+}
+
+func Test_Admin_Pays_With_Callback(t *testing.T) {
+	events := make(chan webhook.PaymentEvent, 1)
+	hook := httptest.NewServer(webhook.NewHandler(webhook.Options{}, webhook.EventHandlerFunc(
+		func(_ context.Context, e webhook.PaymentEvent) error {
+			events <- e
+			return nil
+		},
+	)))
+	defer hook.Close()
+
+	seed := `{"apps": [{"id": "app", "secret": "secret", "name": "Shop", "callback": "` + hook.URL + `"}]}`
+	seedPath := filepath.Join(t.TempDir(), "seed.json")
+	if err := os.WriteFile(seedPath, []byte(seed), 0o600); err != nil {
+		t.Fatalf(err.Error())
+	}
+	srv, _ := newMock(t, seedPath)
+	client := qvapay.NewPaymentAppClient(qvapay.Options{BaseURL: srv.URL, AppID: "app", SecretID: "secret"})
+	invoice, err := client.CreateInvoice(context.Background(), qvapay.MustParseAmount("25.60"), "Enanitos verdes", "BRID56568989")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	res, err := http.Post(srv.URL+"/admin/api/transactions/"+invoice.TransactionUUID+"/pay", "", nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var body struct {
+		Transaction qvapay.Transaction `json:"transaction"`
+	}
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+	assert.Equal(t, qvapay.StatusPaid, body.Transaction.Status)
+	assert.Equal(t, "BRID56568989", (<-events).RemoteID)
+
+	res, err = http.Post(srv.URL+"/admin/api/transactions/"+invoice.TransactionUUID+"/cancel", "", nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+	res, err = http.Get(srv.URL + "/pay/" + invoice.TransactionUUID)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	page, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.True(t, strings.Contains(string(page), "BRID56568989"))
+}
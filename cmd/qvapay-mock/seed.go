@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/kenriortega/qvapay-go/qvapaytest"
+	"gopkg.in/yaml.v3"
+)
+
+// seed is the data loaded in the engine at start, from a YAML or JSON file.
+// Amounts, statuses and dates are written like the API sends them.
+type seed struct {
+	Apps   []seedApp      `json:"apps"`
+	Offers []qvapay.Offer `json:"offers"`
+}
+
+type seedApp struct {
+	ID           string               `json:"id"`
+	Secret       string               `json:"secret"`
+	UserID       int                  `json:"user_id"`
+	Name         string               `json:"name"`
+	URL          string               `json:"url"`
+	Desc         string               `json:"desc"`
+	Callback     string               `json:"callback"`
+	Logo         string               `json:"logo"`
+	Balance      qvapay.Amount        `json:"balance"`
+	Transactions []qvapay.Transaction `json:"transactions"`
+}
+
+// loadSeed reads a seed file, YAML unless its extension is .json.
+func loadSeed(path string) (*seed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		// the YAML goes through JSON to reuse the JSON decoding of the
+		// qvapay types
+		var v any
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("seed %s: %w", path, err)
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("seed %s: %w", path, err)
+		}
+	}
+	s := &seed{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("seed %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// apply adds the seed data to engine.
+func (s *seed) apply(engine *qvapaytest.Engine) error {
+	for _, app := range s.Apps {
+		if app.ID == "" || app.Secret == "" {
+			return fmt.Errorf("seed: app %q without id or secret", app.Name)
+		}
+		engine.AddApp(qvapaytest.App{
+			ID:       app.ID,
+			Secret:   app.Secret,
+			UserID:   app.UserID,
+			Name:     app.Name,
+			URL:      app.URL,
+			Desc:     app.Desc,
+			Callback: app.Callback,
+			Logo:     app.Logo,
+			Balance:  app.Balance,
+		})
+		for _, tx := range app.Transactions {
+			if _, err := engine.AddTransaction(app.ID, tx); err != nil {
+				return fmt.Errorf("seed: app %s: %w", app.ID, err)
+			}
+		}
+	}
+	engine.AddOffers(s.Offers...)
+	return nil
+}
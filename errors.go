@@ -0,0 +1,103 @@
+package qvapay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Sentinel errors, use them with errors.Is to branch on API failures.
+var (
+	ErrUnauthorized = errors.New("qvapay: unauthorized")
+	ErrNotFound     = errors.New("qvapay: not found")
+	ErrRateLimited  = errors.New("qvapay: rate limited")
+	ErrServerError  = errors.New("qvapay: server error")
+	ErrDecode       = errors.New("qvapay: decoding error")
+)
+
+// StatusError is returned when the API answers with an unexpected status code.
+type StatusError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Body is the response body, with secrets redacted.
+	Body string
+	// URL is the requested URL, without the query string.
+	URL string
+	// Message is the decoded API error message, if any.
+	Message string
+}
+
+// statusError builds a *StatusError with the secrets of body redacted.
+func (c *client) statusError(status int, requestUrl *url.URL, body string) *StatusError {
+	e := &StatusError{
+		StatusCode: status,
+		Body:       c.redactor.Redact(body),
+		URL:        safeURL(requestUrl),
+	}
+	apiErr := &APIError{}
+	if json.Unmarshal([]byte(body), apiErr) == nil && apiErr.ErrorMessage != nil {
+		e.Message = c.redactor.Redact(fmt.Sprintf("%v", apiErr.ErrorMessage))
+	}
+	return e
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("unexpected response status %d from %s: %s", e.StatusCode, e.URL, e.Message)
+	}
+	return fmt.Sprintf("unexpected response status %d from %s: %q", e.StatusCode, e.URL, e.Body)
+}
+
+// Is matches the status code against the package sentinel errors.
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServerError:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// DecodeError is returned when a response body can't be decoded,
+// it matches ErrDecode.
+type DecodeError struct {
+	// Body is the response body, with secrets redacted.
+	Body string
+	Err  error
+}
+
+// decodeError builds a *DecodeError with the secrets of body redacted.
+func (c *client) decodeError(body string, err error) *DecodeError {
+	return &DecodeError{Body: c.redactor.Redact(body), Err: err}
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decoding error for data %s: %v", e.Body, e.Err)
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecode
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// safeURL returns the URL without query string and user info, which may
+// carry credentials.
+func safeURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	clean := *u
+	clean.RawQuery = ""
+	clean.User = nil
+	return clean.String()
+}
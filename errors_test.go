@@ -0,0 +1,91 @@
+package qvapay_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Status_Error(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid credentials"}`))
+		}),
+	)
+	defer s.Close()
+
+	client := qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL:  s.URL,
+			AppID:    appID,
+			SecretID: secretID,
+		},
+	)
+	_, err := client.GetTransaction(context.Background(), "6507ee0d")
+	assert.True(t, errors.Is(err, qvapay.ErrUnauthorized))
+	assert.False(t, errors.Is(err, qvapay.ErrServerError))
+
+	var statusErr *qvapay.StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected *StatusError, got %T", err)
+	}
+	assert.Equal(t, http.StatusUnauthorized, statusErr.StatusCode)
+	assert.Equal(t, "invalid credentials", statusErr.Message)
+	assert.Equal(t, s.URL+"/v1/transaction/6507ee0d", statusErr.URL)
+	assert.NotContains(t, err.Error(), secretID)
+}
+
+func Test_Decode_Error(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte(`{"uuid":`))
+		}),
+	)
+	defer s.Close()
+
+	client := qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL:  s.URL,
+			AppID:    appID,
+			SecretID: secretID,
+		},
+	)
+	_, err := client.GetInfo(context.Background())
+	assert.True(t, errors.Is(err, qvapay.ErrDecode))
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken writer")
+}
+
+func Test_Failing_Debug_Writer_Is_Reported(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte(`{"66.0"}`))
+		}),
+	)
+	defer s.Close()
+
+	var reported []error
+	client := qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL:      s.URL,
+			Debug:        failingWriter{},
+			AppID:        appID,
+			SecretID:     secretID,
+			ErrorHandler: func(err error) { reported = append(reported, err) },
+		},
+	)
+	balance, err := client.GetBalance(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, qvapay.MustParseAmount("66.0"), balance)
+	assert.Len(t, reported, 2)
+}
@@ -11,15 +11,20 @@ import (
 func main() {
 	fmt.Println("Get deposit accounts")
 
-	qvaApi, _ := qvapay.QvaPayFactory("qvapay", qvapay.Options{
+	qvaApi, err := qvapay.Open[qvapay.P2PAPI](qvapay.KindQvaPay, qvapay.Options{
 		BaseURL:    qvapay.BaseURL, // constants url base https://qvapay.com/api
 		HttpClient: nil,            // custom http.PaymentAppClient
 		Debug:      os.Stdout,      // debug io.Writter (os.Stdout)
 	})
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	offers, err := qvaApi.Offers(context.Background(), qvapay.QueryParams{Page: 2})
 	if err != nil {
 		log.Fatal(err)
 	}
-	fmt.Println(offers)
+	for _, offer := range offers.Data {
+		fmt.Println(offer.ID, offer.Type, offer.Coin, offer.Amount, offer.Receive)
+	}
 }
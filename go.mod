@@ -1,12 +1,18 @@
 module github.com/kenriortega/qvapay-go
 
-go 1.18
+go 1.23
 
-require github.com/stretchr/testify v1.7.0
+require github.com/stretchr/testify v1.9.0
 
 require (
-	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/kr/pretty v0.3.1 // indirect
+	github.com/rogpeppe/go-internal v1.10.0 // indirect
+	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/joho/godotenv v1.4.0
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
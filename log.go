@@ -0,0 +1,89 @@
+package qvapay
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// callRecord describes a call made through apiCall, after its last attempt.
+type callRecord struct {
+	route    string
+	method   string
+	url      string
+	status   int
+	attempts int
+	latency  time.Duration
+	request  []byte
+	response string
+	err      error
+}
+
+// errorClass returns a short, stable name for the kind of failure of a call,
+// or an empty string if it succeeded.
+func errorClass(status int, err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, ErrRateLimited) || status == http.StatusTooManyRequests:
+		return "rate_limited"
+	case errors.Is(err, ErrDecode):
+		return "decode"
+	case err != nil:
+		return "transport"
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return "unauthorized"
+	case status == http.StatusNotFound:
+		return "not_found"
+	case status >= http.StatusInternalServerError:
+		return "server_error"
+	case status >= http.StatusBadRequest:
+		return "client_error"
+	}
+	return ""
+}
+
+// logCall writes a single structured record for the call, secrets are
+// redacted and bodies are only logged at the debug level.
+func (c *client) logCall(ctx context.Context, r callRecord) {
+	if c.logger == nil {
+		return
+	}
+	class := errorClass(r.status, r.err)
+	level := slog.LevelInfo
+	switch {
+	case r.err != nil || r.status >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case class != "":
+		level = slog.LevelWarn
+	}
+	if !c.logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", r.method),
+		slog.String("route", r.route),
+		slog.String("url", c.redactor.Redact(r.url)),
+		slog.Int("status", r.status),
+		slog.Duration("latency", r.latency),
+		slog.Int("attempt", r.attempts),
+		slog.Int("response_size", len(r.response)),
+	}
+	if class != "" {
+		attrs = append(attrs, slog.String("error_class", class))
+	}
+	if r.err != nil {
+		attrs = append(attrs, slog.String("error", c.redactor.Redact(r.err.Error())))
+	}
+	if c.logger.Enabled(ctx, slog.LevelDebug) {
+		attrs = append(attrs,
+			slog.String("request_body", c.redactor.Redact(string(r.request))),
+			slog.String("response_body", c.redactor.Redact(r.response)),
+		)
+	}
+	c.logger.LogAttrs(ctx, level, "qvapay call", attrs...)
+}
@@ -0,0 +1,80 @@
+package qvapay_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Logger_Record_Per_Call(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte(`{"uuid":"123456789","secret":"123456987"}`))
+		}),
+	)
+	defer s.Close()
+
+	for _, level := range []slog.Level{slog.LevelInfo, slog.LevelDebug} {
+		out := &bytes.Buffer{}
+		client := qvapay.NewPaymentAppClient(
+			qvapay.Options{
+				BaseURL:  s.URL,
+				AppID:    appID,
+				SecretID: secretID,
+				Logger:   slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: level})),
+			},
+		)
+		_, err := client.GetInfo(context.Background())
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		record := map[string]any{}
+		if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+			t.Fatalf(err.Error())
+		}
+		assert.Equal(t, qvapay.RouteInfo, record["route"])
+		assert.Equal(t, http.MethodGet, record["method"])
+		assert.Equal(t, float64(http.StatusOK), record["status"])
+		assert.Equal(t, float64(1), record["attempt"])
+		assert.NotContains(t, out.String(), secretID)
+		assert.NotContains(t, out.String(), "123456987")
+		_, hasBody := record["response_body"]
+		assert.Equal(t, level == slog.LevelDebug, hasBody)
+	}
+}
+
+func Test_Logger_Decode_Error(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte(`{"uuid":`))
+		}),
+	)
+	defer s.Close()
+
+	out := &bytes.Buffer{}
+	client := qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL: s.URL,
+			Logger:  slog.New(slog.NewJSONHandler(out, nil)),
+		},
+	)
+	_, err := client.GetInfo(context.Background())
+	assert.True(t, errors.Is(err, qvapay.ErrDecode))
+
+	record := map[string]any{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "decode", record["error_class"])
+	assert.Equal(t, float64(http.StatusOK), record["status"])
+}
@@ -4,11 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -21,17 +20,22 @@ const (
 
 // Transaction  object
 type Transaction struct {
-	ID           string `json:"uuid,omitempty"`
-	UserID       int    `json:"user_id,omitempty"`
-	AppID        int    `json:"app_id,omitempty"`
-	Amount       string `json:"amount,omitempty"`
-	Description  string `json:"description,omitempty"`
-	RemoteID     string `json:"remote_id,omitempty"`
-	Status       string `json:"status,omitempty"`
-	PaidByUserID int    `json:"paid_by_user_id,omitempty"`
-	Signed       int    `json:"signed,omitempty"`
-	CreatedAt    string `json:"created_at,omitempty"`
-	UpdatedAt    string `json:"updated_at,omitempty"`
+	ID           string            `json:"uuid,omitempty"`
+	UserID       int               `json:"user_id,omitempty"`
+	AppID        int               `json:"app_id,omitempty"`
+	Amount       Amount            `json:"amount,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	RemoteID     string            `json:"remote_id,omitempty"`
+	Status       TransactionStatus `json:"status,omitempty"`
+	PaidByUserID int               `json:"paid_by_user_id,omitempty"`
+	Signed       int               `json:"signed,omitempty"`
+	CreatedAt    Timestamp         `json:"created_at,omitempty"`
+	UpdatedAt    Timestamp         `json:"updated_at,omitempty"`
+}
+
+// Money returns the amount of the transaction, in CurrencyUSD.
+func (tx Transaction) Money() Money {
+	return NewMoney(tx.Amount, CurrencyUSD)
 }
 
 // TransactionPaidBy object
@@ -62,8 +66,57 @@ type Owner struct {
 	Logo     string `json:"logo,omitempty"`
 }
 
+// APIQueryParams are the params of GetTransactions. Page, Status and
+// RemoteID are sent to the API, the other filters are applied client side
+// to the page returned.
 type APIQueryParams struct {
 	Page int
+	// From keeps the transactions created at or after it.
+	From time.Time
+	// To keeps the transactions created before it.
+	To time.Time
+	// Status keeps the transactions in that status.
+	Status TransactionStatus
+	// RemoteID keeps the transactions with that exact remote_id.
+	RemoteID string
+	// RemoteIDPrefix keeps the transactions whose remote_id starts with it.
+	RemoteIDPrefix string
+	// MinAmount keeps the transactions of at least that amount.
+	MinAmount *Amount
+	// MaxAmount keeps the transactions of at most that amount.
+	MaxAmount *Amount
+	// Description keeps the transactions whose description contains it,
+	// ignoring case.
+	Description string
+}
+
+// Match reports whether tx passes every filter of the query.
+func (q APIQueryParams) Match(tx Transaction) bool {
+	switch {
+	case !q.From.IsZero() && tx.CreatedAt.Before(q.From):
+		return false
+	case !q.To.IsZero() && !tx.CreatedAt.Before(q.To):
+		return false
+	case q.Status != "" && tx.Status != q.Status:
+		return false
+	case q.RemoteID != "" && tx.RemoteID != q.RemoteID:
+		return false
+	case q.RemoteIDPrefix != "" && !strings.HasPrefix(tx.RemoteID, q.RemoteIDPrefix):
+		return false
+	case q.MinAmount != nil && tx.Amount.Cmp(*q.MinAmount) < 0:
+		return false
+	case q.MaxAmount != nil && tx.Amount.Cmp(*q.MaxAmount) > 0:
+		return false
+	case q.Description != "" && !strings.Contains(strings.ToLower(tx.Description), strings.ToLower(q.Description)):
+		return false
+	}
+	return true
+}
+
+// apiParams returns the params of the query sent to the API, without the
+// filters applied client side.
+func (q APIQueryParams) apiParams() APIQueryParams {
+	return APIQueryParams{Page: q.Page, Status: q.Status, RemoteID: q.RemoteID}
 }
 
 func formatParams(v url.Values, query APIQueryParams) url.Values {
@@ -73,6 +126,12 @@ func formatParams(v url.Values, query APIQueryParams) url.Values {
 	} else {
 		v.Add("page", fmt.Sprintf("%d", 1))
 	}
+	if query.Status != "" {
+		v.Add("status", query.Status.String())
+	}
+	if query.RemoteID != "" {
+		v.Add("remote_id", query.RemoteID)
+	}
 
 	v.Encode()
 	return v
@@ -92,19 +151,26 @@ type AppInfoResponse struct {
 	Secret   string `json:"secret,omitempty"`
 }
 
-// ToJSON ...
+// ToJSON returns the JSON encoding of the response, or an empty string if it
+// can't be encoded, use EncodeJSON to get the error.
 func (ai *AppInfoResponse) ToJSON() string {
+	s, _ := ai.EncodeJSON()
+	return s
+}
+
+// EncodeJSON returns the JSON encoding of the response.
+func (ai *AppInfoResponse) EncodeJSON() (string, error) {
 	bytes, err := json.Marshal(ai)
 	if err != nil {
-		log.Fatalf(err.Error())
+		return "", err
 	}
-	return string(bytes)
+	return string(bytes), nil
 }
 
 // InvoiceResponse object
 type InvoiceResponse struct {
 	AppID           string `json:"app_id,omitempty"`
-	Amount          string `json:"amount,omitempty"`
+	Amount          Amount `json:"amount,omitempty"`
 	Desciption      string `json:"desciption,omitempty"`
 	RemoteID        string `json:"remote_id,omitempty"`
 	Signed          string `json:"signed,omitempty"`
@@ -113,12 +179,25 @@ type InvoiceResponse struct {
 	SignedUrl       string `json:"signedUrl,omitempty"`
 }
 
+// Money returns the amount of the invoice, in CurrencyUSD.
+func (i *InvoiceResponse) Money() Money {
+	return NewMoney(i.Amount, CurrencyUSD)
+}
+
+// ToJSON returns the JSON encoding of the response, or an empty string if it
+// can't be encoded, use EncodeJSON to get the error.
 func (i *InvoiceResponse) ToJSON() string {
+	s, _ := i.EncodeJSON()
+	return s
+}
+
+// EncodeJSON returns the JSON encoding of the response.
+func (i *InvoiceResponse) EncodeJSON() (string, error) {
 	bytes, err := json.Marshal(i)
 	if err != nil {
-		log.Fatalf(err.Error())
+		return "", err
 	}
-	return string(bytes)
+	return string(bytes), nil
 }
 
 // TransactionsResponse reposnses
@@ -137,208 +216,314 @@ type TransactionsResponse struct {
 	Total        int           `json:"total,omitempty"`
 }
 
+// ToJSON returns the JSON encoding of the response, or an empty string if it
+// can't be encoded, use EncodeJSON to get the error.
 func (txs *TransactionsResponse) ToJSON() string {
+	s, _ := txs.EncodeJSON()
+	return s
+}
+
+// EncodeJSON returns the JSON encoding of the response.
+func (txs *TransactionsResponse) EncodeJSON() (string, error) {
 	bytes, err := json.Marshal(txs)
 	if err != nil {
-		log.Fatalf(err.Error())
+		return "", err
 	}
-	return string(bytes)
+	return string(bytes), nil
 }
 
 // TransactionReponse object
 type TransactionReponse struct {
-	ID                string `json:"uuid,omitempty"`
-	UserID            int    `json:"user_id,omitempty"`
-	AppID             int    `json:"app_id,omitempty"`
-	Amount            string `json:"amount,omitempty"`
-	Description       string `json:"description,omitempty"`
-	RemoteID          string `json:"remote_id,omitempty"`
-	Status            string `json:"status,omitempty"`
-	PaidByUserID      int    `json:"paid_by_user_id,omitempty"`
-	Signed            int    `json:"signed,omitempty"`
-	CreatedAt         string `json:"created_at,omitempty"`
-	UpdatedAt         string `json:"updated_at,omitempty"`
+	ID                string            `json:"uuid,omitempty"`
+	UserID            int               `json:"user_id,omitempty"`
+	AppID             int               `json:"app_id,omitempty"`
+	Amount            Amount            `json:"amount,omitempty"`
+	Description       string            `json:"description,omitempty"`
+	RemoteID          string            `json:"remote_id,omitempty"`
+	Status            TransactionStatus `json:"status,omitempty"`
+	PaidByUserID      int               `json:"paid_by_user_id,omitempty"`
+	Signed            int               `json:"signed,omitempty"`
+	CreatedAt         Timestamp         `json:"created_at,omitempty"`
+	UpdatedAt         Timestamp         `json:"updated_at,omitempty"`
 	TransactionPaidBy `json:"paid_by,omitempty"`
 	App               `json:"app,omitempty"`
 	Owner             `json:"owner,omitempty"`
 }
 
+// Money returns the amount of the transaction, in CurrencyUSD.
+func (tx *TransactionReponse) Money() Money {
+	return NewMoney(tx.Amount, CurrencyUSD)
+}
+
+// ToJSON returns the JSON encoding of the response, or an empty string if it
+// can't be encoded, use EncodeJSON to get the error.
 func (tx *TransactionReponse) ToJSON() string {
+	s, _ := tx.EncodeJSON()
+	return s
+}
+
+// EncodeJSON returns the JSON encoding of the response.
+func (tx *TransactionReponse) EncodeJSON() (string, error) {
 	bytes, err := json.Marshal(tx)
 	if err != nil {
-		log.Fatalf(err.Error())
+		return "", err
 	}
-	return string(bytes)
+	return string(bytes), nil
 }
 
 // GetInfo returns the corresponding object info on fetch call, or an error.
 func (c *client) GetInfo(ctx context.Context) (*AppInfoResponse, error) {
+	call := &Call{Operation: OpGetInfo, Route: RouteInfo}
+	if err := c.invoke(ctx, call); err != nil {
+		return nil, err
+	}
+	result, ok := call.Result.(*AppInfoResponse)
+	if !ok {
+		return nil, resultError(call)
+	}
+	return result, nil
+}
 
+func (c *client) getInfo(ctx context.Context, call *Call) error {
 	requestUrl, err := url.Parse(fmt.Sprintf("%s/%s/%s", c.url, ApiVersion, RouteInfo))
 	if err != nil {
-		return nil, err
+		return err
 	}
-	v := url.Values{}
-	v.Set("app_id", c.appID)
-	v.Add("app_secret", c.appSecret)
+	v := c.credentials()
 	requestUrl.RawQuery = v.Encode()
 
+	result := AppInfoResponse{}
 	status, res, err := c.apiCall(
 		ctx,
+		RouteInfo,
 		http.MethodGet,
 		requestUrl.String(),
+		call.Header,
 		nil,
+		c.decodeJSON(&result),
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if status != http.StatusOK {
-		return nil, fmt.Errorf("unexpected response status %d: %q", status, res)
-	}
-	result := AppInfoResponse{}
-	err = json.NewDecoder(strings.NewReader(res)).Decode(&result)
-	if err != nil {
-		return nil, fmt.Errorf("decoding error for data %s: %v", res, err)
+		return c.statusError(status, requestUrl, res)
 	}
-	return &result, nil
+	call.Result = &result
+	return nil
 }
 
 // CreateInvoice ...
-func (c *client) CreateInvoice(ctx context.Context, amount float64,
+func (c *client) CreateInvoice(ctx context.Context, amount Amount,
 	description string,
 	remoteID string,
 ) (*InvoiceResponse, error) {
+	call := &Call{
+		Operation: OpCreateInvoice,
+		Route:     RouteInvoice,
+		Params: &CreateInvoiceParams{
+			Amount:      amount,
+			Description: description,
+			RemoteID:    remoteID,
+		},
+	}
+	if err := c.invoke(ctx, call); err != nil {
+		return nil, err
+	}
+	result, ok := call.Result.(*InvoiceResponse)
+	if !ok {
+		return nil, resultError(call)
+	}
+	return result, nil
+}
+
+func (c *client) createInvoice(ctx context.Context, call *Call) error {
+	params, ok := call.Params.(*CreateInvoiceParams)
+	if !ok {
+		return paramsError(call)
+	}
 	requestUrl, err := url.Parse(fmt.Sprintf("%s/%s/%s", c.url, ApiVersion, RouteInvoice))
 	if err != nil {
-		return nil, err
+		return err
 	}
-	v := url.Values{}
-	v.Set("app_id", c.appID)
-	v.Add("app_secret", c.appSecret)
-	v.Add("amount", fmt.Sprintf("%f", amount))
-	v.Add("description", description)
-	v.Add("remote_id", remoteID)
-	v.Add("signed", remoteID)
+	v := c.credentials()
+	v.Add("amount", params.Amount.String())
+	v.Add("description", params.Description)
+	v.Add("remote_id", params.RemoteID)
+	v.Add("signed", params.RemoteID)
 	requestUrl.RawQuery = v.Encode()
 
+	result := InvoiceResponse{}
 	status, res, err := c.apiCall(
 		ctx,
+		RouteInvoice,
 		http.MethodGet,
 		requestUrl.String(),
+		call.Header,
 		nil,
+		c.decodeJSON(&result),
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if status != http.StatusOK {
-		return nil, fmt.Errorf("unexpected response status %d: %q", status, res)
-	}
-	result := InvoiceResponse{}
-	err = json.NewDecoder(strings.NewReader(res)).Decode(&result)
-	if err != nil {
-		return nil, fmt.Errorf("decoding error for data %s: %v", res, err)
+		return c.statusError(status, requestUrl, res)
 	}
-	return &result, nil
+	call.Result = &result
+	return nil
 }
 
-// GetTransactions ...
+// GetTransactions returns a page of transactions, only the transactions
+// of the page that match every filter of query are kept, so a page may hold
+// less than PerPage transactions while Total counts the unfiltered ones.
 func (c *client) GetTransactions(ctx context.Context, query APIQueryParams) (*TransactionsResponse, error) {
+	call := &Call{Operation: OpGetTransactions, Route: RouteTxs, Params: &query}
+	if err := c.invoke(ctx, call); err != nil {
+		return nil, err
+	}
+	result, ok := call.Result.(*TransactionsResponse)
+	if !ok {
+		return nil, resultError(call)
+	}
+	return result, nil
+}
+
+func (c *client) getTransactions(ctx context.Context, call *Call) error {
+	query, ok := call.Params.(*APIQueryParams)
+	if !ok {
+		return paramsError(call)
+	}
 	requestUrl, err := url.Parse(fmt.Sprintf("%s/%s/%s", c.url, ApiVersion, RouteTxs))
 	if err != nil {
-		return nil, err
+		return err
 	}
-	v := url.Values{}
-	v.Set("app_id", c.appID)
-	v.Add("app_secret", c.appSecret)
-	v = formatParams(v, query)
+	v := c.credentials()
+	v = formatParams(v, *query)
 	requestUrl.RawQuery = v.Encode()
 
+	result := TransactionsResponse{}
 	status, res, err := c.apiCall(
 		ctx,
+		RouteTxs,
 		http.MethodGet,
 		requestUrl.String(),
+		call.Header,
 		nil,
+		c.decodeJSON(&result),
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if status != http.StatusOK {
-		return nil, fmt.Errorf("unexpected response status %d: %q", status, res)
+		return c.statusError(status, requestUrl, res)
 	}
-	result := TransactionsResponse{}
-	err = json.NewDecoder(strings.NewReader(res)).Decode(&result)
-	if err != nil {
-		return nil, fmt.Errorf("decoding error for data %s: %v", res, err)
+	kept := result.Data[:0]
+	for _, tx := range result.Data {
+		if query.Match(tx) {
+			kept = append(kept, tx)
+		}
 	}
-	return &result, nil
+	result.Data = kept
+	call.Result = &result
+	return nil
 }
 
 // GetTransaction ...
 func (c *client) GetTransaction(ctx context.Context, id string) (*TransactionReponse, error) {
-	requestUrl, err := url.Parse(fmt.Sprintf("%s/%s/%s/%s", c.url, ApiVersion, RouteTx, id))
-	if err != nil {
+	call := &Call{Operation: OpGetTransaction, Route: RouteTx, Params: &GetTransactionParams{ID: id}}
+	if err := c.invoke(ctx, call); err != nil {
 		return nil, err
 	}
-	v := url.Values{}
-	v.Set("app_id", c.appID)
-	v.Add("app_secret", c.appSecret)
+	result, ok := call.Result.(*TransactionReponse)
+	if !ok {
+		return nil, resultError(call)
+	}
+	return result, nil
+}
+
+func (c *client) getTransaction(ctx context.Context, call *Call) error {
+	params, ok := call.Params.(*GetTransactionParams)
+	if !ok {
+		return paramsError(call)
+	}
+	requestUrl, err := url.Parse(fmt.Sprintf("%s/%s/%s/%s", c.url, ApiVersion, RouteTx, params.ID))
+	if err != nil {
+		return err
+	}
+	v := c.credentials()
 	requestUrl.RawQuery = v.Encode()
 
+	result := TransactionReponse{}
 	status, res, err := c.apiCall(
 		ctx,
+		RouteTx,
 		http.MethodGet,
 		requestUrl.String(),
+		call.Header,
 		nil,
+		c.decodeJSON(&result),
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if status != http.StatusOK {
-		return nil, fmt.Errorf("unexpected response status %d: %q", status, res)
-	}
-	result := TransactionReponse{}
-	err = json.NewDecoder(strings.NewReader(res)).Decode(&result)
-	if err != nil {
-		return nil, fmt.Errorf("decoding error for data %s: %v", res, err)
+		return c.statusError(status, requestUrl, res)
 	}
-	return &result, nil
+	call.Result = &result
+	return nil
 }
 
 // GetBalance ...
 //
 // GET https://qvapay.com/api/v1/balance?app_id={app_id}&app_secret={app_secret}
 //
-// {
-// 	"66.00"
-// }
-func (c *client) GetBalance(ctx context.Context) (float64, error) {
+//	{
+//		"66.00"
+//	}
+func (c *client) GetBalance(ctx context.Context) (Amount, error) {
+	call := &Call{Operation: OpGetBalance, Route: RouteBalance}
+	if err := c.invoke(ctx, call); err != nil {
+		return Amount{}, err
+	}
+	result, ok := call.Result.(*Amount)
+	if !ok {
+		return Amount{}, resultError(call)
+	}
+	return *result, nil
+}
+
+func (c *client) getBalance(ctx context.Context, call *Call) error {
 	requestUrl, err := url.Parse(fmt.Sprintf("%s/%s/%s", c.url, ApiVersion, RouteBalance))
 	if err != nil {
-		return 0, err
+		return err
 	}
-	v := url.Values{}
-	v.Set("app_id", c.appID)
-	v.Add("app_secret", c.appSecret)
+	v := c.credentials()
 	requestUrl.RawQuery = v.Encode()
 
+	var result Amount
 	status, res, err := c.apiCall(
 		ctx,
+		RouteBalance,
 		http.MethodGet,
 		requestUrl.String(),
+		call.Header,
 		nil,
+		func(res string) error {
+			firstParser := strings.ReplaceAll(res, `{"`, "")
+			respParsered := strings.ReplaceAll(firstParser, `"}`, "")
+
+			var err error
+			if result, err = ParseAmount(respParsered); err != nil {
+				return c.decodeError(res, err)
+			}
+			return nil
+		},
 	)
 	if err != nil {
-		return 0, err
+		return err
 	}
 	if status != http.StatusOK {
-		return 0, fmt.Errorf("unexpected response status %d: %q", status, res)
+		return c.statusError(status, requestUrl, res)
 	}
-	firstParser := strings.ReplaceAll(res, `{"`, "")
-	respParsered := strings.ReplaceAll(firstParser, `"}`, "")
-
-	result, err := strconv.ParseFloat(respParsered, 64)
-	if err != nil {
-		return 0, err
-	}
-	return result, nil
+	call.Result = &result
+	return nil
 }
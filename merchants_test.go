@@ -5,7 +5,6 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
-	"strconv"
 	"testing"
 	"time"
 
@@ -78,7 +77,7 @@ func Test_Get_Info(t *testing.T) {
 }
 
 func Test_Create_Invoice(t *testing.T) {
-	amountInput := 25.60
+	amountInput := qvapay.MustParseAmount("25.60")
 	s := httptest.NewServer(
 		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 			time.Sleep(10 * time.Millisecond)
@@ -111,12 +110,7 @@ func Test_Create_Invoice(t *testing.T) {
 	if err != nil {
 		t.Fatalf(err.Error())
 	}
-	result, err := strconv.ParseFloat(invoice.Amount, 64)
-	if err != nil {
-		t.Fatalf(err.Error())
-	}
-
-	assert.Equal(t, amountInput, result)
+	assert.Equal(t, amountInput, invoice.Amount)
 
 }
 
@@ -246,7 +240,7 @@ func Test_Get_Tx(t *testing.T) {
 }
 func Test_Get_Balance(t *testing.T) {
 
-	expected := 66.0
+	expected := qvapay.MustParseAmount("66.0")
 	s := httptest.NewServer(
 		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 			time.Sleep(10 * time.Millisecond)
@@ -274,3 +268,84 @@ func Test_Get_Balance(t *testing.T) {
 	assert.Equal(t, expected, balance)
 
 }
+
+func Test_Get_Txs_Server_Filters(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "paid", r.URL.Query().Get("status"))
+			assert.Equal(t, "BRID56568989", r.URL.Query().Get("remote_id"))
+			assert.Equal(t, "1", r.URL.Query().Get("page"))
+			w.Write([]byte(`{"current_page": 1, "data": [], "last_page": 1}`))
+		}),
+	)
+	defer s.Close()
+	client := qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL:  s.URL,
+			AppID:    appID,
+			SecretID: secretID,
+		},
+	)
+	query := qvapay.APIQueryParams{Status: qvapay.StatusPaid, RemoteID: "BRID56568989"}
+	_, err := client.GetTransactions(context.Background(), query)
+	assert.NoError(t, err)
+}
+
+func Test_Get_Txs_Client_Filters(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.URL.Query().Get("remote_id"))
+			w.Write([]byte(`{"current_page": 1, "last_page": 2, "total": 20, "data": [
+				{"uuid": "1", "remote_id": "ORD-1", "amount": "25.60"},
+				{"uuid": "2", "remote_id": "ORD-2", "amount": "5"},
+				{"uuid": "3", "remote_id": "INV-3", "amount": "25.60"}
+			]}`))
+		}),
+	)
+	defer s.Close()
+	client := qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL:  s.URL,
+			AppID:    appID,
+			SecretID: secretID,
+		},
+	)
+	minAmount := qvapay.MustParseAmount("10")
+	query := qvapay.APIQueryParams{RemoteIDPrefix: "ORD-", MinAmount: &minAmount}
+	txs, err := client.GetTransactions(context.Background(), query)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if assert.Len(t, txs.Data, 1) {
+		assert.Equal(t, "1", txs.Data[0].ID)
+	}
+	assert.Equal(t, 20, txs.Total)
+}
+
+func Test_List_Txs_Filtered_Pages(t *testing.T) {
+	pages := map[string]string{
+		"1": `{"current_page": 1, "last_page": 2, "data": [{"uuid": "1", "status": "pending"}]}`,
+		"2": `{"current_page": 2, "last_page": 2, "data": [{"uuid": "2", "status": "paid"}]}`,
+	}
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// a server ignoring the status filter
+			w.Write([]byte(pages[r.URL.Query().Get("page")]))
+		}),
+	)
+	defer s.Close()
+	client := qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL:  s.URL,
+			AppID:    appID,
+			SecretID: secretID,
+		},
+	)
+	txs, err := qvapay.ListTransactions(context.Background(), client, qvapay.APIQueryParams{Status: qvapay.StatusPaid})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if assert.Len(t, txs, 1) {
+		assert.Equal(t, "2", txs[0].ID)
+	}
+}
@@ -0,0 +1,22 @@
+package qvapay
+
+import "time"
+
+// Client kinds, the names of the built-in backends of Open.
+const (
+	KindApp    = "app"
+	KindQvaPay = "qvapay"
+)
+
+// Metrics collects the calls made through a client, see the qvapayprom
+// package for a Prometheus implementation. Every method receives the client
+// kind (KindApp or KindQvaPay) and the route (RouteInfo, RouteBalance...).
+type Metrics interface {
+	// CallStarted is called before the first attempt of a call.
+	CallStarted(kind string, route string)
+	// CallFinished is called after the last attempt of a call, errorClass
+	// is empty when the call succeeded.
+	CallFinished(kind string, route string, status int, errorClass string, latency time.Duration)
+	// CallRetried is called before every attempt after the first one.
+	CallRetried(kind string, route string)
+}
@@ -0,0 +1,120 @@
+package qvapay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Call is an IQvaPay method call going through the middleware chain.
+type Call struct {
+	// Operation is the name of the IQvaPay method (OpGetInfo, OpOffers...).
+	Operation string
+	// Route is the API route of the operation (RouteInfo, RouteOffers...).
+	Route string
+	// Params are the typed params of the operation:
+	//  - OpCreateInvoice: *CreateInvoiceParams
+	//  - OpGetTransactions: *APIQueryParams
+	//  - OpGetTransaction: *GetTransactionParams
+	//  - OpOffers: *QueryParams
+	//  - OpGetInfo, OpGetBalance: nil
+	Params any
+	// Header are extra headers sent with the HTTP request.
+	Header http.Header
+	// Result is the decoded response, set once the call succeeded:
+	//  - OpGetInfo: *AppInfoResponse
+	//  - OpCreateInvoice: *InvoiceResponse
+	//  - OpGetTransactions: *TransactionsResponse
+	//  - OpGetTransaction: *TransactionReponse
+	//  - OpGetBalance: *Amount
+	//  - OpOffers: *OffersPage
+	Result any
+}
+
+// CreateInvoiceParams are the params of OpCreateInvoice.
+type CreateInvoiceParams struct {
+	Amount      Amount
+	Description string
+	RemoteID    string
+}
+
+// GetTransactionParams are the params of OpGetTransaction.
+type GetTransactionParams struct {
+	ID string
+}
+
+// Doer performs a Call, the innermost Doer of a client sends the HTTP
+// request and sets the Call result.
+type Doer interface {
+	Do(ctx context.Context, call *Call) error
+}
+
+// DoerFunc is a function that implements Doer.
+type DoerFunc func(ctx context.Context, call *Call) error
+
+func (f DoerFunc) Do(ctx context.Context, call *Call) error {
+	return f(ctx, call)
+}
+
+// Middleware wraps a Doer, it can inspect or change the call before calling
+// next, inspect the result after, or answer without calling next at all.
+type Middleware func(next Doer) Doer
+
+// chain wraps d with the middlewares, the first one being the outermost.
+func chain(d Doer, middlewares []Middleware) Doer {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		d = middlewares[i](d)
+	}
+	return d
+}
+
+// BearerAuth returns a middleware that sends token as a bearer token.
+func BearerAuth(token string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(ctx context.Context, call *Call) error {
+			call.Header.Set("Authorization", "Bearer "+token)
+			return next.Do(ctx, call)
+		})
+	}
+}
+
+// invoke traces the call and runs it through the middleware chain.
+func (c *client) invoke(ctx context.Context, call *Call) (err error) {
+	ctx, span := c.startSpan(ctx, call.Operation, call.Route, paramsAttributes(call)...)
+	defer func() { span.finish(err) }()
+	if call.Header == nil {
+		call.Header = http.Header{}
+	}
+	if err = c.doer.Do(ctx, call); err != nil {
+		return err
+	}
+	span.setAttributes(resultAttributes(call)...)
+	return nil
+}
+
+// send is the innermost Doer, it calls the API.
+func (c *client) send(ctx context.Context, call *Call) error {
+	switch call.Operation {
+	case OpGetInfo:
+		return c.getInfo(ctx, call)
+	case OpCreateInvoice:
+		return c.createInvoice(ctx, call)
+	case OpGetTransactions:
+		return c.getTransactions(ctx, call)
+	case OpGetTransaction:
+		return c.getTransaction(ctx, call)
+	case OpGetBalance:
+		return c.getBalance(ctx, call)
+	case OpOffers:
+		return c.offers(ctx, call)
+	}
+	return fmt.Errorf("qvapay: unknown operation %q", call.Operation)
+}
+
+func paramsError(call *Call) error {
+	return fmt.Errorf("qvapay: unexpected params type %T for %s", call.Params, call.Operation)
+}
+
+func resultError(call *Call) error {
+	return fmt.Errorf("qvapay: unexpected result type %T for %s", call.Result, call.Operation)
+}
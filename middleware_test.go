@@ -0,0 +1,71 @@
+package qvapay_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Middleware_Chain(t *testing.T) {
+	var calls int32
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			assert.Equal(t, "Bearer myToken", r.Header.Get("Authorization"))
+			w.Write([]byte(`{"uuid":"6507ee0d","remote_id":"15803"}`))
+		}),
+	)
+	defer s.Close()
+
+	var seen []string
+	recorder := func(next qvapay.Doer) qvapay.Doer {
+		return qvapay.DoerFunc(func(ctx context.Context, call *qvapay.Call) error {
+			params := call.Params.(*qvapay.GetTransactionParams)
+			seen = append(seen, call.Operation+":"+params.ID)
+			err := next.Do(ctx, call)
+			if err == nil {
+				seen = append(seen, call.Result.(*qvapay.TransactionReponse).RemoteID)
+			}
+			return err
+		})
+	}
+	cache := map[string]*qvapay.TransactionReponse{}
+	caching := func(next qvapay.Doer) qvapay.Doer {
+		return qvapay.DoerFunc(func(ctx context.Context, call *qvapay.Call) error {
+			id := call.Params.(*qvapay.GetTransactionParams).ID
+			if tx, ok := cache[id]; ok {
+				call.Result = tx
+				return nil
+			}
+			if err := next.Do(ctx, call); err != nil {
+				return err
+			}
+			cache[id] = call.Result.(*qvapay.TransactionReponse)
+			return nil
+		})
+	}
+
+	client := qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL:     s.URL,
+			Middlewares: []qvapay.Middleware{recorder, caching, qvapay.BearerAuth("myToken")},
+		},
+	)
+	for i := 0; i < 2; i++ {
+		tx, err := client.GetTransaction(context.Background(), "6507ee0d")
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		assert.Equal(t, "15803", tx.RemoteID)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, []string{
+		qvapay.OpGetTransaction + ":6507ee0d", "15803",
+		qvapay.OpGetTransaction + ":6507ee0d", "15803",
+	}, seen)
+}
@@ -0,0 +1,330 @@
+package qvapay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// AmountScale is the number of fractional digits kept by an Amount.
+const AmountScale = 8
+
+const amountUnit = 100000000 // 10^AmountScale
+
+var (
+	// ErrCurrencyMismatch is returned when operating on Money of different
+	// currencies.
+	ErrCurrencyMismatch = errors.New("qvapay: currency mismatch")
+	// ErrAmountOverflow is the panic value, wrapped, of the Amount
+	// operations whose result doesn't fit an Amount.
+	ErrAmountOverflow = errors.New("qvapay: amount overflow")
+	// ErrAmountPrecision is the panic value, wrapped, of NewAmount when the
+	// value has more than AmountScale fractional digits.
+	ErrAmountPrecision = errors.New("qvapay: amount precision lost")
+)
+
+// CurrencyUSD is the currency of the QvaPay balances and invoices.
+const CurrencyUSD = "USD"
+
+// Amount is an exact decimal amount with AmountScale fractional digits, its
+// zero value is 0. It's (un)marshalled from the JSON strings and numbers
+// returned by the API and marshalled as a string, e.g. "25.60".
+type Amount struct {
+	units int64
+}
+
+// NewAmount returns the amount value * 10^exp, e.g. NewAmount(2560, -2) is
+// 25.60. It panics when the amount is out of range or has more than
+// AmountScale fractional digits, use ParseAmount for untrusted input.
+func NewAmount(value int64, exp int) Amount {
+	a := Amount{units: value}
+	for i := exp + AmountScale; i > 0; i-- {
+		if a.units > math.MaxInt64/10 || a.units < math.MinInt64/10 {
+			panic(fmt.Errorf("%w: %de%d", ErrAmountOverflow, value, exp))
+		}
+		a.units *= 10
+	}
+	for i := exp + AmountScale; i < 0; i++ {
+		if a.units%10 != 0 {
+			panic(fmt.Errorf("%w: %de%d", ErrAmountPrecision, value, exp))
+		}
+		a.units /= 10
+	}
+	return a
+}
+
+// ParseAmount parses a decimal string such as "25.60", "-3" or "0.00000001".
+// It fails on more than AmountScale fractional digits.
+func ParseAmount(s string) (Amount, error) {
+	str := strings.TrimSpace(s)
+	neg := false
+	switch {
+	case strings.HasPrefix(str, "-"):
+		neg = true
+		str = str[1:]
+	case strings.HasPrefix(str, "+"):
+		str = str[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(str, ".")
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return Amount{}, fmt.Errorf("qvapay: invalid amount %q", s)
+	}
+	if len(fracPart) > AmountScale {
+		return Amount{}, fmt.Errorf("qvapay: amount %q has more than %d decimals", s, AmountScale)
+	}
+	fracPart += strings.Repeat("0", AmountScale-len(fracPart))
+	units, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return Amount{}, fmt.Errorf("qvapay: amount %q out of range", s)
+	}
+	if neg {
+		units = -units
+	}
+	return Amount{units: units}, nil
+}
+
+// MustParseAmount is like ParseAmount but panics on error, it's meant for
+// constants and tests.
+func MustParseAmount(s string) Amount {
+	a, err := ParseAmount(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Add returns a + b, it panics with ErrAmountOverflow when the result is out
+// of range, see CheckedAdd.
+func (a Amount) Add(b Amount) Amount {
+	return must(a.CheckedAdd(b))
+}
+
+// Sub returns a - b, it panics with ErrAmountOverflow when the result is out
+// of range, see CheckedSub.
+func (a Amount) Sub(b Amount) Amount {
+	return must(a.CheckedSub(b))
+}
+
+// Mul returns a * n, it panics with ErrAmountOverflow when the result is out
+// of range, see CheckedMul.
+func (a Amount) Mul(n int64) Amount {
+	return must(a.CheckedMul(n))
+}
+
+// CheckedAdd returns a + b, or an error wrapping ErrAmountOverflow when the
+// result is out of range.
+func (a Amount) CheckedAdd(b Amount) (Amount, error) {
+	if b.units > 0 && a.units > math.MaxInt64-b.units ||
+		b.units < 0 && a.units < math.MinInt64-b.units {
+		return Amount{}, fmt.Errorf("%w: %s + %s", ErrAmountOverflow, a, b)
+	}
+	return Amount{units: a.units + b.units}, nil
+}
+
+// CheckedSub returns a - b, or an error wrapping ErrAmountOverflow when the
+// result is out of range.
+func (a Amount) CheckedSub(b Amount) (Amount, error) {
+	if b.units < 0 && a.units > math.MaxInt64+b.units ||
+		b.units > 0 && a.units < math.MinInt64+b.units {
+		return Amount{}, fmt.Errorf("%w: %s - %s", ErrAmountOverflow, a, b)
+	}
+	return Amount{units: a.units - b.units}, nil
+}
+
+// CheckedMul returns a * n, or an error wrapping ErrAmountOverflow when the
+// result is out of range.
+func (a Amount) CheckedMul(n int64) (Amount, error) {
+	if a.units == 0 || n == 0 {
+		return Amount{}, nil
+	}
+	units := a.units * n
+	if units/n != a.units || a.units == -1 && n == math.MinInt64 || n == -1 && a.units == math.MinInt64 {
+		return Amount{}, fmt.Errorf("%w: %s * %d", ErrAmountOverflow, a, n)
+	}
+	return Amount{units: units}, nil
+}
+
+// must panics with err, the Amount operations panic like integer division
+// by zero, the library itself uses the checked ones.
+func must(a Amount, err error) Amount {
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// Neg returns -a, it panics with ErrAmountOverflow for the lowest Amount.
+func (a Amount) Neg() Amount {
+	if a.units == math.MinInt64 {
+		panic(fmt.Errorf("%w: -(%s)", ErrAmountOverflow, a))
+	}
+	return Amount{units: -a.units}
+}
+
+// Cmp returns -1, 0 or +1 if a is lower, equal or greater than b.
+func (a Amount) Cmp(b Amount) int {
+	switch {
+	case a.units < b.units:
+		return -1
+	case a.units > b.units:
+		return 1
+	}
+	return 0
+}
+
+// Equal reports whether a and b are the same amount.
+func (a Amount) Equal(b Amount) bool {
+	return a.units == b.units
+}
+
+// IsZero reports whether a is 0.
+func (a Amount) IsZero() bool {
+	return a.units == 0
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of a.
+func (a Amount) Sign() int {
+	return a.Cmp(Amount{})
+}
+
+// Float64 returns the nearest float64, for display purposes only.
+func (a Amount) Float64() float64 {
+	return float64(a.units) / amountUnit
+}
+
+// String formats the amount with at least two decimals, e.g. "25.60" or
+// "0.00000001".
+func (a Amount) String() string {
+	s := a.StringFixed(AmountScale)
+	trimmed := strings.TrimRight(s, "0")
+	if dot := strings.IndexByte(s, '.'); len(trimmed) < dot+3 {
+		trimmed = s[:dot+3]
+	}
+	return trimmed
+}
+
+// StringFixed formats the amount with the given number of decimals,
+// truncating the extra ones.
+func (a Amount) StringFixed(decimals int) string {
+	if decimals > AmountScale {
+		decimals = AmountScale
+	}
+	sign := ""
+	u := uint64(a.units)
+	if a.units < 0 {
+		sign = "-"
+		u = uint64(-a.units)
+		if a.units == math.MinInt64 {
+			u = uint64(math.MaxInt64) + 1
+		}
+	}
+	s := fmt.Sprintf("%s%d.%08d", sign, u/amountUnit, u%amountUnit)
+	if decimals <= 0 {
+		return s[:strings.IndexByte(s, '.')]
+	}
+	return s[:len(s)-AmountScale+decimals]
+}
+
+// MarshalJSON encodes the amount as a JSON string.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON decodes a JSON string, number or null.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*a = Amount{}
+		return nil
+	}
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			*a = Amount{}
+			return nil
+		}
+	}
+	parsed, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Money is an Amount in a currency.
+type Money struct {
+	Amount   Amount `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// NewMoney returns amount in currency.
+func NewMoney(amount Amount, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Add returns m + o, both must be in the same currency. It fails with
+// ErrAmountOverflow when the sum is out of range.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	sum, err := m.Amount.CheckedAdd(o.Amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// In returns the amount of m, which must be in currency, e.g. to create an
+// invoice, which is always in CurrencyUSD:
+//
+//	amount, err := price.In(qvapay.CurrencyUSD)
+func (m Money) In(currency string) (Amount, error) {
+	if m.Currency != currency {
+		return Amount{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, currency)
+	}
+	return m.Amount, nil
+}
+
+// Sub returns m - o, both must be in the same currency. It fails with
+// ErrAmountOverflow when the difference is out of range.
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	diff, err := m.Amount.CheckedSub(o.Amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: diff, Currency: m.Currency}, nil
+}
+
+// Cmp compares m and o like Amount.Cmp, both must be in the same currency.
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency {
+		return 0, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return m.Amount.Cmp(o.Amount), nil
+}
+
+// String formats the money as "25.60 USD".
+func (m Money) String() string {
+	return m.Amount.String() + " " + m.Currency
+}
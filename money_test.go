@@ -0,0 +1,123 @@
+package qvapay_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Parse_Amount(t *testing.T) {
+	cases := map[string]string{
+		"25.60":      "25.60",
+		"25.6":       "25.60",
+		"66":         "66.00",
+		"-3.5":       "-3.50",
+		"0.00000001": "0.00000001",
+		".5":         "0.50",
+	}
+	for in, expected := range cases {
+		a, err := qvapay.ParseAmount(in)
+		if assert.NoError(t, err, in) {
+			assert.Equal(t, expected, a.String())
+		}
+	}
+	for _, in := range []string{"", "abc", "1.2.3", "1e5", "0.000000001"} {
+		_, err := qvapay.ParseAmount(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func Test_Amount_Arithmetic_Is_Exact(t *testing.T) {
+	sum := qvapay.Amount{}
+	for i := 0; i < 10; i++ {
+		sum = sum.Add(qvapay.MustParseAmount("0.10"))
+	}
+	assert.True(t, sum.Equal(qvapay.MustParseAmount("1")))
+	assert.Equal(t, 1, sum.Cmp(qvapay.MustParseAmount("0.99")))
+	assert.Equal(t, "-0.01", qvapay.MustParseAmount("0.99").Sub(sum).String())
+	assert.Equal(t, qvapay.MustParseAmount("25.60"), qvapay.NewAmount(2560, -2))
+	assert.Equal(t, "76.80", qvapay.NewAmount(2560, -2).Mul(3).String())
+	assert.Equal(t, "25.6", qvapay.MustParseAmount("25.68").StringFixed(1))
+}
+
+func Test_Amount_Overflow(t *testing.T) {
+	top := qvapay.MustParseAmount("92233720368")
+	overflows := map[string]func(){
+		"add":       func() { top.Add(qvapay.MustParseAmount("1")) },
+		"sub":       func() { top.Neg().Sub(qvapay.MustParseAmount("1")) },
+		"mul":       func() { top.Mul(2) },
+		"exp":       func() { qvapay.NewAmount(1, 11) },
+		"precision": func() { qvapay.NewAmount(1, -10) },
+	}
+	for name, fn := range overflows {
+		assert.Panics(t, fn, name)
+	}
+	func() {
+		defer func() {
+			err, _ := recover().(error)
+			assert.True(t, errors.Is(err, qvapay.ErrAmountOverflow))
+		}()
+		top.Add(top)
+	}()
+	_, err := top.CheckedAdd(qvapay.MustParseAmount("1"))
+	assert.True(t, errors.Is(err, qvapay.ErrAmountOverflow))
+	_, err = top.Neg().CheckedSub(qvapay.MustParseAmount("1"))
+	assert.True(t, errors.Is(err, qvapay.ErrAmountOverflow))
+	_, err = top.CheckedMul(2)
+	assert.True(t, errors.Is(err, qvapay.ErrAmountOverflow))
+	sum, err := top.CheckedAdd(top.Neg())
+	assert.NoError(t, err)
+	assert.True(t, sum.IsZero())
+
+	big := qvapay.NewMoney(qvapay.MustParseAmount("90000000000"), qvapay.CurrencyUSD)
+	_, err = big.Add(big)
+	assert.True(t, errors.Is(err, qvapay.ErrAmountOverflow))
+	_, err = qvapay.NewMoney(big.Amount.Neg(), qvapay.CurrencyUSD).Sub(big)
+	assert.True(t, errors.Is(err, qvapay.ErrAmountOverflow))
+
+	assert.Equal(t, "0.00000001", qvapay.NewAmount(10, -9).String())
+	assert.Equal(t, "-92233720368.00", top.Mul(-1).String())
+}
+
+func Test_Amount_JSON(t *testing.T) {
+	var v struct {
+		A qvapay.Amount `json:"a"`
+		B qvapay.Amount `json:"b"`
+		C qvapay.Amount `json:"c"`
+	}
+	err := json.Unmarshal([]byte(`{"a":"25.60","b":30.1,"c":null}`), &v)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, "25.60", v.A.String())
+	assert.Equal(t, "30.10", v.B.String())
+	assert.True(t, v.C.IsZero())
+
+	out, err := json.Marshal(v)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":"25.60","b":"30.10","c":"0.00"}`, string(out))
+}
+
+func Test_Money_Currency(t *testing.T) {
+	usd := qvapay.NewMoney(qvapay.MustParseAmount("10"), qvapay.CurrencyUSD)
+	total, err := usd.Add(qvapay.NewMoney(qvapay.MustParseAmount("0.5"), qvapay.CurrencyUSD))
+	assert.NoError(t, err)
+	assert.Equal(t, "10.50 USD", total.String())
+
+	_, err = usd.Add(qvapay.NewMoney(qvapay.MustParseAmount("1"), "BTC"))
+	assert.True(t, errors.Is(err, qvapay.ErrCurrencyMismatch))
+}
+
+func Test_Money_Of_Responses(t *testing.T) {
+	tx := qvapay.Transaction{Amount: qvapay.MustParseAmount("25.60")}
+	assert.Equal(t, "25.60 USD", tx.Money().String())
+
+	amount, err := tx.Money().In(qvapay.CurrencyUSD)
+	assert.NoError(t, err)
+	assert.Equal(t, tx.Amount, amount)
+	_, err = qvapay.NewMoney(amount, "BTC").In(qvapay.CurrencyUSD)
+	assert.True(t, errors.Is(err, qvapay.ErrCurrencyMismatch))
+}
@@ -4,38 +4,117 @@ package qvapay
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"net/url"
-	"strings"
 )
 
+// RouteOffers is the public P2P offers route
+const RouteOffers = "p2p/index"
+
+// OfferType is the side of a P2P offer.
+type OfferType string
+
+const (
+	OfferBuy  OfferType = "buy"
+	OfferSell OfferType = "sell"
+)
+
+// Offer object
+type Offer struct {
+	ID        string    `json:"uuid,omitempty"`
+	Type      OfferType `json:"type,omitempty"`
+	Coin      string    `json:"coin,omitempty"`
+	Amount    Amount    `json:"amount,omitempty"`
+	Receive   Amount    `json:"receive,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	Owner     Owner     `json:"owner,omitempty"`
+	Rating    float64   `json:"rating,omitempty"`
+	CreatedAt Timestamp `json:"created_at,omitempty"`
+	UpdatedAt Timestamp `json:"updated_at,omitempty"`
+	// Raw is the offer as sent by the API, with the fields the SDK doesn't
+	// know about.
+	Raw json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the offer and keeps a copy of data in Raw.
+func (o *Offer) UnmarshalJSON(data []byte) error {
+	type offer Offer
+	if err := json.Unmarshal(data, (*offer)(o)); err != nil {
+		return err
+	}
+	o.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// OffersPage is a page of P2P offers.
+type OffersPage struct {
+	CurrentPage  int     `json:"current_page,omitempty"`
+	Data         []Offer `json:"data,omitempty"`
+	FirstPageURL string  `json:"first_page_url,omitempty"`
+	From         int     `json:"from,omitempty"`
+	LastPage     int     `json:"last_page,omitempty"`
+	LastPageURL  string  `json:"last_page_url,omitempty"`
+	NextPageURL  string  `json:"next_page_url,omitempty"`
+	Path         string  `json:"path,omitempty"`
+	PerPage      int     `json:"per_page,omitempty"`
+	PrevPageURL  string  `json:"prev_page_url,omitempty"`
+	To           int     `json:"to,omitempty"`
+	Total        int     `json:"total,omitempty"`
+	// Raw is the page as sent by the API.
+	Raw json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the page and keeps a copy of data in Raw.
+func (p *OffersPage) UnmarshalJSON(data []byte) error {
+	type page OffersPage
+	if err := json.Unmarshal(data, (*page)(p)); err != nil {
+		return err
+	}
+	p.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
 // Offers
 // curl --location --request GET 'https://qvapay.com/api/p2p/index'
-func (c *client) Offers(ctx context.Context, query QueryParams) (map[string]any, error) {
-	requestUrl, err := url.Parse(c.url + "/p2p/index")
-	if err != nil {
+func (c *client) Offers(ctx context.Context, query QueryParams) (*OffersPage, error) {
+	call := &Call{Operation: OpOffers, Route: RouteOffers, Params: &query}
+	if err := c.invoke(ctx, call); err != nil {
 		return nil, err
 	}
-	ParseUrlQueryParams(query, requestUrl)
+	result, ok := call.Result.(*OffersPage)
+	if !ok {
+		return nil, resultError(call)
+	}
+	return result, nil
+}
 
+func (c *client) offers(ctx context.Context, call *Call) error {
+	query, ok := call.Params.(*QueryParams)
+	if !ok {
+		return paramsError(call)
+	}
+	requestUrl, err := url.Parse(c.url + "/" + RouteOffers)
+	if err != nil {
+		return err
+	}
+	ParseUrlQueryParams(*query, requestUrl)
+
+	result := OffersPage{}
 	status, res, err := c.apiCall(
 		ctx,
+		RouteOffers,
 		http.MethodGet,
 		requestUrl.String(),
+		call.Header,
 		nil,
+		c.decodeJSON(&result),
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if status != http.StatusOK {
-		return nil, HandleAPIErrorResponse(res)
-	}
-	result := map[string]any{}
-
-	err = json.NewDecoder(strings.NewReader(res)).Decode(&result)
-	if err != nil {
-		return nil, fmt.Errorf("decoding error for data %s: %v", res, err)
+		return c.statusError(status, requestUrl, res)
 	}
-	return result, nil
+	call.Result = &result
+	return nil
 }
@@ -0,0 +1,65 @@
+package qvapay_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Offers(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/"+qvapay.RouteOffers, r.URL.Path)
+			assert.Equal(t, "2", r.URL.Query().Get("page"))
+			w.Write([]byte(
+				`{
+					"current_page": 2,
+					"data": [
+						{
+							"uuid": "a0f1d3a4-2f3e-4c53-9d6b-7d2ab8c5e1f0",
+							"type": "sell",
+							"coin": "BANK_CUP",
+							"amount": "50.00",
+							"receive": 6000,
+							"status": "open",
+							"owner": {"uuid": "796a9e01", "username": "qvapay-owner"},
+							"rating": 4.5,
+							"created_at": "2022-05-01T10:00:00.000000Z",
+							"only_kyc": 1
+						}
+					],
+					"last_page": 3,
+					"next_page_url": "https://qvapay.com/api/p2p/index?page=3",
+					"per_page": 1,
+					"total": 3
+				}`,
+			))
+		}),
+	)
+	defer s.Close()
+
+	client := qvapay.NewQvaPay(qvapay.Options{BaseURL: s.URL})
+	page, err := client.Offers(context.Background(), qvapay.QueryParams{Page: 2})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, 3, page.LastPage)
+	if !assert.Len(t, page.Data, 1) {
+		return
+	}
+	offer := page.Data[0]
+	assert.Equal(t, qvapay.OfferSell, offer.Type)
+	assert.Equal(t, "BANK_CUP", offer.Coin)
+	assert.Equal(t, qvapay.MustParseAmount("6000"), offer.Receive)
+	assert.Equal(t, "qvapay-owner", offer.Owner.Username)
+	assert.Equal(t, 4.5, offer.Rating)
+
+	extra := map[string]any{}
+	assert.NoError(t, json.Unmarshal(offer.Raw, &extra))
+	assert.Equal(t, float64(1), extra["only_kyc"])
+}
@@ -0,0 +1,246 @@
+package qvapay
+
+import (
+	"context"
+	"iter"
+)
+
+// TransactionLister is the part of IQvaPay needed to walk the transactions.
+type TransactionLister interface {
+	GetTransactions(ctx context.Context, query APIQueryParams) (*TransactionsResponse, error)
+}
+
+// IteratorOptions configures a TransactionIterator.
+type IteratorOptions struct {
+	// Prefetch fetches the next page while the current one is consumed.
+	Prefetch bool
+}
+
+type pageResult struct {
+	page *TransactionsResponse
+	err  error
+}
+
+// TransactionIterator walks every page of transactions lazily, starting at
+// the page of the query. Transactions already returned are skipped, they
+// show up again when new transactions shift the pages while paging.
+//
+//	it := qvapay.NewTransactionIterator(ctx, client, qvapay.APIQueryParams{}, qvapay.IteratorOptions{})
+//	defer it.Close()
+//	for it.Next() {
+//		tx := it.Transaction()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type TransactionIterator struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	api        TransactionLister
+	query      APIQueryParams
+	opts       IteratorOptions
+	items      []Transaction
+	current    Transaction
+	next       chan pageResult
+	page       int
+	done       bool
+	closed     bool
+	err        error
+	seen       map[string]struct{}
+	duplicates int
+}
+
+// NewTransactionIterator returns an iterator over every transaction matching
+// query, Close must be called when the iterator isn't drained.
+func NewTransactionIterator(
+	ctx context.Context,
+	api TransactionLister,
+	query APIQueryParams,
+	opts IteratorOptions,
+) *TransactionIterator {
+	ctx, cancel := context.WithCancel(ctx)
+	page := query.Page
+	if page < 1 {
+		page = 1
+	}
+	return &TransactionIterator{
+		ctx:    ctx,
+		cancel: cancel,
+		api:    api,
+		query:  query,
+		opts:   opts,
+		page:   page,
+		seen:   map[string]struct{}{},
+	}
+}
+
+// fetch gets a page in the background.
+func (it *TransactionIterator) fetch(page int) chan pageResult {
+	ch := make(chan pageResult, 1)
+	// the pages are filtered here, a page emptied by the client side
+	// filters isn't the last one
+	query := it.query.apiParams()
+	query.Page = page
+	go func() {
+		res, err := it.api.GetTransactions(it.ctx, query)
+		ch <- pageResult{page: res, err: err}
+	}()
+	return ch
+}
+
+// Next advances to the next transaction, it returns false at the end of the
+// last page, on error or when the context is done.
+func (it *TransactionIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		// a closed iterator ends without error
+		if !it.closed {
+			it.err = err
+		}
+		return false
+	}
+	for len(it.items) == 0 {
+		if it.done || !it.nextPage() {
+			return false
+		}
+	}
+	it.current, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+func (it *TransactionIterator) nextPage() bool {
+	if it.next == nil {
+		it.next = it.fetch(it.page)
+	}
+	var res pageResult
+	select {
+	case <-it.ctx.Done():
+		it.err = it.ctx.Err()
+		return false
+	case res = <-it.next:
+	}
+	it.next = nil
+	if res.err != nil {
+		it.err = res.err
+		return false
+	}
+	page := res.page
+	if page == nil {
+		page = &TransactionsResponse{}
+	}
+	items, unseen := it.filter(page.Data)
+	if isLastPage(page, it.page, unseen) {
+		it.done = true
+	}
+	it.page++
+	if !it.done && it.opts.Prefetch {
+		it.next = it.fetch(it.page)
+	}
+	it.items = items
+	return true
+}
+
+// isLastPage reports whether the walk ends at the page current. LastPage,
+// or else NextPageURL, decide it: a page can be empty and still be followed
+// by others, e.g. when the lister filters its pages. Without either, the
+// walk ends on a page without unseen transactions.
+func isLastPage(page *TransactionsResponse, current int, unseen int) bool {
+	switch {
+	case page.LastPage > 0:
+		return current >= page.LastPage
+	case page.NextPageURL != "":
+		return false
+	}
+	return unseen == 0
+}
+
+// filter drops the transactions already returned and the ones that don't
+// match the query, it returns the transactions kept and how many weren't
+// seen before.
+func (it *TransactionIterator) filter(data []Transaction) ([]Transaction, int) {
+	items := make([]Transaction, 0, len(data))
+	unseen := 0
+	for _, tx := range data {
+		if tx.ID != "" {
+			if _, ok := it.seen[tx.ID]; ok {
+				it.duplicates++
+				continue
+			}
+			it.seen[tx.ID] = struct{}{}
+		}
+		unseen++
+		if it.query.Match(tx) {
+			items = append(items, tx)
+		}
+	}
+	return items, unseen
+}
+
+// Transaction returns the current transaction.
+func (it *TransactionIterator) Transaction() Transaction {
+	return it.current
+}
+
+// Err returns the error that stopped the iterator, if any.
+func (it *TransactionIterator) Err() error {
+	return it.err
+}
+
+// Duplicates returns how many transactions were skipped because a previous
+// page already had them, a non zero value means the pages shifted while
+// paging.
+func (it *TransactionIterator) Duplicates() int {
+	return it.duplicates
+}
+
+// Close stops the iterator and any page being prefetched.
+func (it *TransactionIterator) Close() {
+	it.closed = true
+	it.cancel()
+}
+
+// AllTransactions returns an iterator over every transaction matching query,
+// the iteration stops after yielding an error.
+//
+//	for tx, err := range qvapay.AllTransactions(ctx, client, qvapay.APIQueryParams{}, qvapay.IteratorOptions{}) {
+//	}
+func AllTransactions(
+	ctx context.Context,
+	api TransactionLister,
+	query APIQueryParams,
+	opts IteratorOptions,
+) iter.Seq2[Transaction, error] {
+	return func(yield func(Transaction, error) bool) {
+		it := NewTransactionIterator(ctx, api, query, opts)
+		defer it.Close()
+		for it.Next() {
+			if !yield(it.Transaction(), nil) {
+				return
+			}
+		}
+		if err := it.Err(); err != nil {
+			yield(Transaction{}, err)
+		}
+	}
+}
+
+// ListTransactions walks every page and returns the transactions matching
+// query, e.g. every paid transaction of March for the ORD- orders:
+//
+//	txs, err := qvapay.ListTransactions(ctx, client, qvapay.APIQueryParams{
+//		Status:         qvapay.StatusPaid,
+//		RemoteIDPrefix: "ORD-",
+//		From:           time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC),
+//		To:             time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC),
+//	})
+func ListTransactions(ctx context.Context, api TransactionLister, query APIQueryParams) ([]Transaction, error) {
+	var txs []Transaction
+	for tx, err := range AllTransactions(ctx, api, query, IteratorOptions{Prefetch: true}) {
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
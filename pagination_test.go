@@ -0,0 +1,183 @@
+package qvapay_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// pagedTransactions serves ids newest first, perPage at a time, and can
+// insert new transactions on top after a given page is served.
+type pagedTransactions struct {
+	mu       sync.Mutex
+	ids      []string
+	perPage  int
+	requests []int
+	onPage   func(page int, p *pagedTransactions)
+}
+
+func (p *pagedTransactions) GetTransactions(_ context.Context, query qvapay.APIQueryParams) (*qvapay.TransactionsResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, query.Page)
+	lastPage := (len(p.ids) + p.perPage - 1) / p.perPage
+	res := &qvapay.TransactionsResponse{CurrentPage: query.Page, LastPage: lastPage, PerPage: p.perPage}
+	for i := (query.Page - 1) * p.perPage; i < query.Page*p.perPage && i < len(p.ids); i++ {
+		res.Data = append(res.Data, qvapay.Transaction{ID: p.ids[i]})
+	}
+	if p.onPage != nil {
+		p.onPage(query.Page, p)
+	}
+	return res, nil
+}
+
+func newPagedTransactions(n int, perPage int) *pagedTransactions {
+	p := &pagedTransactions{perPage: perPage}
+	for i := n; i > 0; i-- {
+		p.ids = append(p.ids, fmt.Sprintf("tx-%02d", i))
+	}
+	return p
+}
+
+func collect(t *testing.T, api qvapay.TransactionLister, opts qvapay.IteratorOptions) []string {
+	var ids []string
+	for tx, err := range qvapay.AllTransactions(context.Background(), api, qvapay.APIQueryParams{}, opts) {
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		ids = append(ids, tx.ID)
+	}
+	return ids
+}
+
+func Test_All_Transactions(t *testing.T) {
+	for _, prefetch := range []bool{false, true} {
+		api := newPagedTransactions(7, 3)
+		ids := collect(t, api, qvapay.IteratorOptions{Prefetch: prefetch})
+		assert.Equal(t, api.ids, ids)
+		assert.Equal(t, []int{1, 2, 3}, api.requests)
+	}
+}
+
+func Test_Transaction_Iterator_Skips_Shifted_Items(t *testing.T) {
+	api := newPagedTransactions(6, 3)
+	api.onPage = func(page int, p *pagedTransactions) {
+		if page == 1 {
+			p.ids = append([]string{"tx-new"}, p.ids...)
+		}
+	}
+	it := qvapay.NewTransactionIterator(context.Background(), api, qvapay.APIQueryParams{}, qvapay.IteratorOptions{})
+	defer it.Close()
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Transaction().ID)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{"tx-06", "tx-05", "tx-04", "tx-03", "tx-02", "tx-01"}, ids)
+	assert.Equal(t, 1, it.Duplicates())
+}
+
+func Test_Transaction_Iterator_Stops_On_Cancel(t *testing.T) {
+	api := newPagedTransactions(9, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var err error
+	count := 0
+	for _, iterErr := range qvapay.AllTransactions(ctx, api, qvapay.APIQueryParams{}, qvapay.IteratorOptions{}) {
+		if iterErr != nil {
+			err = iterErr
+			break
+		}
+		count++
+		if count == 3 {
+			cancel()
+		}
+	}
+	assert.Equal(t, 3, count)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+type staticTransactions struct {
+	queries []qvapay.APIQueryParams
+	pages   [][]qvapay.Transaction
+}
+
+func (s *staticTransactions) GetTransactions(_ context.Context, query qvapay.APIQueryParams) (*qvapay.TransactionsResponse, error) {
+	s.queries = append(s.queries, query)
+	return &qvapay.TransactionsResponse{
+		CurrentPage: query.Page,
+		LastPage:    len(s.pages),
+		Data:        s.pages[query.Page-1],
+	}, nil
+}
+
+func Test_Transaction_Iterator_Walks_Past_Empty_Pages(t *testing.T) {
+	api := &staticTransactions{pages: [][]qvapay.Transaction{
+		{{ID: "1"}},
+		{},
+		{{ID: "3"}},
+	}}
+	for _, opts := range []qvapay.IteratorOptions{{}, {Prefetch: true}} {
+		api.queries = nil
+		assert.Equal(t, []string{"1", "3"}, collect(t, api, opts))
+		assert.Len(t, api.queries, 3)
+	}
+}
+
+// unpagedTransactions answers the same transactions for every page, without
+// LastPage nor NextPageURL.
+type unpagedTransactions struct {
+	requests int
+}
+
+func (u *unpagedTransactions) GetTransactions(context.Context, qvapay.APIQueryParams) (*qvapay.TransactionsResponse, error) {
+	u.requests++
+	return &qvapay.TransactionsResponse{Data: []qvapay.Transaction{{ID: "1"}, {ID: "2"}}}, nil
+}
+
+func Test_Transaction_Iterator_Without_Page_Info(t *testing.T) {
+	api := &unpagedTransactions{}
+	assert.Equal(t, []string{"1", "2"}, collect(t, api, qvapay.IteratorOptions{}))
+	assert.Equal(t, 2, api.requests)
+}
+
+func Test_List_Transactions_Filters(t *testing.T) {
+	march := func(day int) qvapay.Timestamp {
+		return qvapay.Timestamp{Time: time.Date(2022, time.March, day, 12, 0, 0, 0, time.UTC)}
+	}
+	api := &staticTransactions{pages: [][]qvapay.Transaction{
+		{
+			{ID: "1", RemoteID: "ORD-1", Status: qvapay.StatusPaid, Amount: qvapay.MustParseAmount("10"), CreatedAt: march(31)},
+			{ID: "2", RemoteID: "ORD-2", Status: qvapay.StatusPending, Amount: qvapay.MustParseAmount("10"), CreatedAt: march(20)},
+			{ID: "3", RemoteID: "INV-3", Status: qvapay.StatusPaid, Amount: qvapay.MustParseAmount("10"), CreatedAt: march(15)},
+		},
+		{
+			{ID: "4", RemoteID: "ORD-4", Status: qvapay.StatusPaid, Amount: qvapay.MustParseAmount("99.99"), CreatedAt: march(2)},
+			{ID: "5", RemoteID: "ORD-5", Status: qvapay.StatusPaid, Amount: qvapay.MustParseAmount("5"), CreatedAt: qvapay.Timestamp{Time: time.Date(2022, time.February, 28, 0, 0, 0, 0, time.UTC)}},
+		},
+	}}
+
+	minAmount := qvapay.MustParseAmount("10")
+	txs, err := qvapay.ListTransactions(context.Background(), api, qvapay.APIQueryParams{
+		Status:         qvapay.StatusPaid,
+		RemoteIDPrefix: "ORD-",
+		MinAmount:      &minAmount,
+		From:           time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC),
+		To:             time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	var ids []string
+	for _, tx := range txs {
+		ids = append(ids, tx.ID)
+	}
+	assert.Equal(t, []string{"1", "4"}, ids)
+	assert.Len(t, api.queries, 2)
+}
@@ -0,0 +1,114 @@
+// Package qvapayotel implements the qvapay.Tracer hooks with OpenTelemetry.
+package qvapayotel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/kenriortega/qvapay-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the OpenTelemetry tracer.
+const InstrumentationName = "github.com/kenriortega/qvapay-go"
+
+// Tracer is a qvapay.Tracer backed by an OpenTelemetry tracer provider.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// Option configures a Tracer.
+type Option func(*config)
+
+type config struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+// WithTracerProvider sets the tracer provider, defaults to the global one.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = provider
+	}
+}
+
+// WithPropagator sets the propagator used to inject the outgoing headers,
+// defaults to the global one.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = propagator
+	}
+}
+
+// NewTracer returns a Tracer to set on qvapay.Options.
+func NewTracer(opts ...Option) *Tracer {
+	cfg := &config{
+		provider:   otel.GetTracerProvider(),
+		propagator: otel.GetTextMapPropagator(),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return &Tracer{
+		tracer:     cfg.provider.Tracer(InstrumentationName),
+		propagator: cfg.propagator,
+	}
+}
+
+// Start starts a client span named after the operation.
+func (t *Tracer) Start(ctx context.Context, operation string, attrs ...qvapay.Attribute) (context.Context, qvapay.Span) {
+	ctx, s := t.tracer.Start(ctx, "qvapay."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(convert(attrs)...),
+	)
+	return ctx, span{s}
+}
+
+// Inject writes the propagation headers of the span in ctx into header.
+func (t *Tracer) Inject(ctx context.Context, header http.Header) {
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+type span struct {
+	span trace.Span
+}
+
+func (s span) SetAttributes(attrs ...qvapay.Attribute) {
+	s.span.SetAttributes(convert(attrs)...)
+}
+
+func (s span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s span) End() {
+	s.span.End()
+}
+
+func convert(attrs []qvapay.Attribute) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		switch v := a.Value.(type) {
+		case string:
+			kvs = append(kvs, attribute.String(a.Key, v))
+		case int:
+			kvs = append(kvs, attribute.Int(a.Key, v))
+		case int64:
+			kvs = append(kvs, attribute.Int64(a.Key, v))
+		case float64:
+			kvs = append(kvs, attribute.Float64(a.Key, v))
+		case bool:
+			kvs = append(kvs, attribute.Bool(a.Key, v))
+		default:
+			kvs = append(kvs, attribute.String(a.Key, fmt.Sprint(v)))
+		}
+	}
+	return kvs
+}
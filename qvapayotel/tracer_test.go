@@ -0,0 +1,87 @@
+package qvapayotel_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/kenriortega/qvapay-go/qvapayotel"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_Span_Per_Call(t *testing.T) {
+	var traceparent string
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			traceparent = r.Header.Get("traceparent")
+			w.Write([]byte(`{"uuid":"6507ee0d","remote_id":"15803","status":"pending"}`))
+		}),
+	)
+	defer s.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	client := qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL:  s.URL,
+			AppID:    "myAppID",
+			SecretID: "mySecretID",
+			Tracer: qvapayotel.NewTracer(
+				qvapayotel.WithTracerProvider(provider),
+				qvapayotel.WithPropagator(propagation.TraceContext{}),
+			),
+		},
+	)
+	_, err := client.GetTransaction(context.Background(), "6507ee0d")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	spans := exporter.GetSpans()
+	if !assert.Len(t, spans, 1) {
+		return
+	}
+	span := spans[0]
+	assert.Equal(t, "qvapay.GetTransaction", span.Name)
+	assert.Contains(t, traceparent, span.SpanContext.TraceID().String())
+
+	attrs := map[string]string{}
+	for _, kv := range span.Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	assert.Equal(t, qvapay.RouteTx, attrs[qvapay.AttrRoute])
+	assert.Equal(t, "6507ee0d", attrs[qvapay.AttrTransactionUUID])
+	assert.Equal(t, "15803", attrs[qvapay.AttrRemoteID])
+	assert.Equal(t, "200", attrs[qvapay.AttrStatusCode])
+}
+
+func Test_Span_Records_Error(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}),
+	)
+	defer s.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	client := qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL: s.URL,
+			Tracer:  qvapayotel.NewTracer(qvapayotel.WithTracerProvider(provider)),
+		},
+	)
+	_, err := client.GetBalance(context.Background())
+	assert.Error(t, err)
+
+	spans := exporter.GetSpans()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+	}
+}
@@ -0,0 +1,95 @@
+// Package qvapayprom implements the qvapay.Metrics hooks with Prometheus
+// collectors.
+package qvapayprom
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics is a qvapay.Metrics and a prometheus.Collector, register it once
+// and share it between clients.
+type Metrics struct {
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+	retries  *prometheus.CounterVec
+	inFlight *prometheus.GaugeVec
+}
+
+var _ qvapay.Metrics = (*Metrics)(nil)
+
+// NewMetrics returns the collectors, named with the given namespace
+// (e.g. "qvapay_requests_total" for "qvapay").
+func NewMetrics(namespace string) *Metrics {
+	labels := []string{"kind", "route"}
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Calls made to the QvaPay API, by status code.",
+		}, append(labels, "code")),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the calls to the QvaPay API, retries included.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "errors_total",
+			Help:      "Failed calls to the QvaPay API, by error type.",
+		}, append(labels, "type")),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retries_total",
+			Help:      "Retried attempts of calls to the QvaPay API.",
+		}, labels),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "in_flight_requests",
+			Help:      "Calls to the QvaPay API in progress.",
+		}, labels),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.latency.Describe(ch)
+	m.errors.Describe(ch)
+	m.retries.Describe(ch)
+	m.inFlight.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.latency.Collect(ch)
+	m.errors.Collect(ch)
+	m.retries.Collect(ch)
+	m.inFlight.Collect(ch)
+}
+
+// CallStarted implements qvapay.Metrics.
+func (m *Metrics) CallStarted(kind string, route string) {
+	m.inFlight.WithLabelValues(kind, route).Inc()
+}
+
+// CallFinished implements qvapay.Metrics.
+func (m *Metrics) CallFinished(kind string, route string, status int, errorClass string, latency time.Duration) {
+	m.inFlight.WithLabelValues(kind, route).Dec()
+	m.requests.WithLabelValues(kind, route, strconv.Itoa(status)).Inc()
+	m.latency.WithLabelValues(kind, route).Observe(latency.Seconds())
+	if errorClass != "" {
+		m.errors.WithLabelValues(kind, route, errorClass).Inc()
+	}
+}
+
+// CallRetried implements qvapay.Metrics.
+func (m *Metrics) CallRetried(kind string, route string) {
+	m.retries.WithLabelValues(kind, route).Inc()
+}
@@ -0,0 +1,89 @@
+package qvapayprom_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/kenriortega/qvapay-go/qvapayprom"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Metrics_Per_Route(t *testing.T) {
+	var calls int32
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(`{"66.0"}`))
+		}),
+	)
+	defer s.Close()
+
+	metrics := qvapayprom.NewMetrics("qvapay")
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(metrics)
+
+	client := qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL: s.URL,
+			Metrics: metrics,
+			Retry: &qvapay.RetryPolicy{
+				MaxAttempts: 2,
+				BaseDelay:   time.Millisecond,
+				RetryStatus: []int{http.StatusBadGateway},
+			},
+		},
+	)
+	_, err := client.GetBalance(context.Background())
+	assert.NoError(t, err)
+
+	expected := `
+# HELP qvapay_requests_total Calls made to the QvaPay API, by status code.
+# TYPE qvapay_requests_total counter
+qvapay_requests_total{code="200",kind="app",route="balance"} 1
+# HELP qvapay_retries_total Retried attempts of calls to the QvaPay API.
+# TYPE qvapay_retries_total counter
+qvapay_retries_total{kind="app",route="balance"} 1
+# HELP qvapay_in_flight_requests Calls to the QvaPay API in progress.
+# TYPE qvapay_in_flight_requests gauge
+qvapay_in_flight_requests{kind="app",route="balance"} 0
+`
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"qvapay_requests_total", "qvapay_retries_total", "qvapay_in_flight_requests")
+	assert.NoError(t, err)
+}
+
+func Test_Metrics_Decode_Error(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte(`{"uuid":`))
+		}),
+	)
+	defer s.Close()
+
+	metrics := qvapayprom.NewMetrics("qvapay")
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(metrics)
+
+	client := qvapay.NewPaymentAppClient(qvapay.Options{BaseURL: s.URL, Metrics: metrics})
+	_, err := client.GetInfo(context.Background())
+	assert.Error(t, err)
+
+	expected := `
+# HELP qvapay_errors_total Failed calls to the QvaPay API, by error type.
+# TYPE qvapay_errors_total counter
+qvapay_errors_total{kind="app",route="info",type="decode"} 1
+`
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected), "qvapay_errors_total")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,302 @@
+package qvapaytest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/kenriortega/qvapay-go"
+)
+
+// ErrNoInteraction is returned by a Replayer for a request missing from
+// its cassette.
+var ErrNoInteraction = errors.New("qvapaytest: no recorded interaction")
+
+// Interaction is a request and its response, a line of a JSONL cassette.
+// Secrets are redacted before the interaction is written.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the request of an Interaction.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// Route is the API route of the URL (info, transaction/{id},
+	// p2p/index...), without the base URL.
+	Route  string      `json:"route"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is the response of an Interaction.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// credentialKeys are the query params ignored to match a request, a
+// cassette recorded with some credentials replays with any others.
+var credentialKeys = []string{"app_id", "app_secret"}
+
+// RecorderOptions configures a Recorder.
+type RecorderOptions struct {
+	// Transport sends the requests, http.DefaultTransport by default.
+	Transport http.RoundTripper
+	// RedactKeys are masked along with qvapay.DefaultRedactKeys.
+	RedactKeys []string
+}
+
+// Recorder is an http.RoundTripper that writes every request it sends and
+// its response to a JSONL cassette, to be served back by a Replayer.
+//
+//	rec, _ := qvapaytest.CreateCassette("testdata/invoice.jsonl", qvapaytest.RecorderOptions{})
+//	defer rec.Close()
+//	client := qvapay.NewPaymentAppClient(qvapay.Options{Transport: rec, BaseURL: sandboxURL})
+type Recorder struct {
+	opts     RecorderOptions
+	redactor *qvapay.Redactor
+	closer   io.Closer
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewRecorder returns a Recorder writing the cassette to w.
+func NewRecorder(w io.Writer, opts RecorderOptions) *Recorder {
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+	return &Recorder{opts: opts, redactor: qvapay.NewRedactor(opts.RedactKeys...), w: w}
+}
+
+// CreateCassette returns a Recorder writing to the file path, truncated,
+// Close closes the file.
+func CreateCassette(path string, opts RecorderOptions) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r := NewRecorder(f, opts)
+	r.closer = f
+	return r, nil
+}
+
+// RoundTrip sends the request and records it with its response.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		reqBody, err = io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	resp, err := r.opts.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	line, err := json.Marshal(Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    r.redactor.Redact(req.URL.String()),
+			Route:  routeOf(req.URL.Path),
+			Header: r.redactHeader(req.Header),
+			Body:   r.redactor.Redact(string(reqBody)),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.redactHeader(resp.Header),
+			Body:       r.redactor.Redact(string(respBody)),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.w.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("qvapaytest: recording %s: %w", routeOf(req.URL.Path), err)
+	}
+	return resp, nil
+}
+
+// redactHeader masks the headers named after the redact keys and the
+// bearer tokens.
+func (r *Recorder) redactHeader(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	redacted := make(http.Header, len(h))
+	for k, vs := range h {
+		for _, v := range vs {
+			prefix := k + ": "
+			redacted[k] = append(redacted[k], strings.TrimPrefix(r.redactor.Redact(prefix+v), prefix))
+		}
+	}
+	return redacted
+}
+
+// Close closes the cassette file of CreateCassette.
+func (r *Recorder) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// ReadCassette reads the interactions of a JSONL cassette.
+func ReadCassette(rd io.Reader) ([]Interaction, error) {
+	var interactions []Interaction
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var i Interaction
+		if err := json.Unmarshal(line, &i); err != nil {
+			return nil, fmt.Errorf("qvapaytest: cassette line %d: %w", n, err)
+		}
+		interactions = append(interactions, i)
+	}
+	return interactions, scanner.Err()
+}
+
+// Replayer is an http.RoundTripper that answers the responses of a
+// cassette, without network. A request matches an interaction with the same
+// method, route and query, the credentials aside. The interactions of a
+// request are served in the order they were recorded, the last one is
+// repeated once they are all used, e.g. a transaction polled until paid.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer returns a Replayer of interactions.
+func NewReplayer(interactions []Interaction) *Replayer {
+	return &Replayer{interactions: interactions, used: make([]bool, len(interactions))}
+}
+
+// OpenCassette returns a Replayer of the cassette file path.
+func OpenCassette(path string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	interactions, err := ReadCassette(f)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(interactions), nil
+}
+
+// RoundTrip answers the next recorded response of the request.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	route, query := routeOf(req.URL.Path), normalizeQuery(req.URL.RawQuery)
+
+	r.mu.Lock()
+	last := -1
+	for i, in := range r.interactions {
+		if in.Request.Method != req.Method || in.Request.Route != route || recordedQuery(in) != query {
+			continue
+		}
+		last = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if last >= 0 {
+		r.used[last] = true
+	}
+	r.mu.Unlock()
+	if last < 0 {
+		return nil, fmt.Errorf("%w: %s %s?%s", ErrNoInteraction, req.Method, route, query)
+	}
+
+	recorded := r.interactions[last].Response
+	header := recorded.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Unused returns the interactions never served, e.g. to check a test made
+// every recorded call.
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []Interaction
+	for i, in := range r.interactions {
+		if !r.used[i] {
+			unused = append(unused, in)
+		}
+	}
+	return unused
+}
+
+func recordedQuery(in Interaction) string {
+	u, err := url.Parse(in.Request.URL)
+	if err != nil {
+		return ""
+	}
+	return normalizeQuery(u.RawQuery)
+}
+
+// normalizeQuery sorts the query params and drops the credentials.
+func normalizeQuery(rawQuery string) string {
+	q, _ := url.ParseQuery(rawQuery)
+	for _, k := range credentialKeys {
+		q.Del(k)
+	}
+	return q.Encode()
+}
+
+// routeOf returns the API route of a URL path, the part after the API
+// version, whatever the base URL.
+func routeOf(path string) string {
+	if i := strings.Index(path, "/"+qvapay.ApiVersion+"/"); i >= 0 {
+		return path[i+len(qvapay.ApiVersion)+2:]
+	}
+	if strings.HasSuffix(path, "/"+qvapay.RouteOffers) {
+		return qvapay.RouteOffers
+	}
+	return strings.TrimPrefix(path, "/")
+}
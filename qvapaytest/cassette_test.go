@@ -0,0 +1,94 @@
+package qvapaytest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/kenriortega/qvapay-go/qvapaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Record_And_Replay(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newServer(t, "")
+	var cassette bytes.Buffer
+	opts := s.AppOptions(appID)
+	opts.Transport = qvapaytest.NewRecorder(&cassette, qvapaytest.RecorderOptions{})
+	client := qvapay.NewPaymentAppClient(opts)
+
+	invoice, err := client.CreateInvoice(ctx, qvapay.MustParseAmount("25.60"), "Enanitos verdes", "BRID56568989")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = client.GetTransaction(ctx, invoice.TransactionUUID)
+	assert.NoError(t, err)
+	_, err = s.Engine().Pay(ctx, invoice.TransactionUUID)
+	assert.NoError(t, err)
+	_, err = client.GetTransaction(ctx, invoice.TransactionUUID)
+	assert.NoError(t, err)
+	assert.False(t, strings.Contains(cassette.String(), secretID))
+
+	interactions, err := qvapaytest.ReadCassette(&cassette)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if assert.Len(t, interactions, 3) {
+		assert.Equal(t, qvapay.RouteInvoice, interactions[0].Request.Route)
+		assert.Equal(t, qvapay.RouteTx+"/"+invoice.TransactionUUID, interactions[1].Request.Route)
+	}
+
+	// replayed offline, with other credentials
+	replayer := qvapaytest.NewReplayer(interactions)
+	offline := qvapay.NewPaymentAppClient(qvapay.Options{
+		BaseURL:   "http://qvapay.invalid/api",
+		AppID:     "other",
+		SecretID:  "other",
+		Transport: replayer,
+	})
+	replayed, err := offline.CreateInvoice(ctx, qvapay.MustParseAmount("25.60"), "Enanitos verdes", "BRID56568989")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, invoice.TransactionUUID, replayed.TransactionUUID)
+	for _, want := range []qvapay.TransactionStatus{qvapay.StatusPending, qvapay.StatusPaid, qvapay.StatusPaid} {
+		tx, err := offline.GetTransaction(ctx, invoice.TransactionUUID)
+		assert.NoError(t, err)
+		assert.Equal(t, want, tx.Status)
+	}
+	assert.Empty(t, replayer.Unused())
+
+	_, err = offline.GetBalance(ctx)
+	assert.True(t, errors.Is(err, qvapaytest.ErrNoInteraction))
+}
+
+func Test_Cassette_File(t *testing.T) {
+	s, _ := newServer(t, "")
+	path := filepath.Join(t.TempDir(), "info.jsonl")
+	rec, err := qvapaytest.CreateCassette(path, qvapaytest.RecorderOptions{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	opts := s.AppOptions(appID)
+	opts.Transport = rec
+	opts.Credentials = qvapay.CredentialsInHeader
+	_, err = qvapay.NewPaymentAppClient(opts).GetInfo(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, rec.Close())
+
+	replayer, err := qvapaytest.OpenCassette(path)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	unused := replayer.Unused()
+	if assert.Len(t, unused, 1) {
+		assert.Equal(t, qvapay.Redacted, unused[0].Request.Header.Get("app-secret"))
+	}
+	info, err := qvapay.NewPaymentAppClient(qvapay.Options{Transport: replayer}).GetInfo(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Enanitos", info.Name)
+}
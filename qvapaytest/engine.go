@@ -0,0 +1,474 @@
+package qvapaytest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kenriortega/qvapay-go"
+)
+
+// DefaultPerPage is the size of the transaction and offer pages, the one
+// of the API.
+const DefaultPerPage = 15
+
+// App is an app registered in the Engine.
+type App struct {
+	ID       string        `json:"id"`
+	Secret   string        `json:"secret,omitempty"`
+	UserID   int           `json:"user_id,omitempty"`
+	Name     string        `json:"name,omitempty"`
+	URL      string        `json:"url,omitempty"`
+	Desc     string        `json:"desc,omitempty"`
+	Callback string        `json:"callback,omitempty"`
+	Logo     string        `json:"logo,omitempty"`
+	Balance  qvapay.Amount `json:"balance"`
+}
+
+// EngineOptions configures an Engine.
+type EngineOptions struct {
+	// BaseURL is the base of the URLs in the responses, qvapay.BaseURL by
+	// default.
+	BaseURL string
+	// PerPage is the size of the pages, DefaultPerPage by default.
+	PerPage int
+	// Now is the clock of the transactions, time.Now by default.
+	Now func() time.Time
+	// HTTPClient sends the webhook callbacks, a client with a 10s timeout
+	// by default.
+	HTTPClient *http.Client
+}
+
+// Engine is the state of a fake QvaPay: apps, transactions and P2P offers.
+// It's safe for concurrent use, the Server and the Fake serve it.
+type Engine struct {
+	opts EngineOptions
+
+	mu     sync.Mutex
+	apps   map[string]*appState
+	txs    map[string]*txState
+	offers []qvapay.Offer
+}
+
+type appState struct {
+	App
+	num int
+	// transaction uuids, oldest first
+	txs []string
+}
+
+type txState struct {
+	appID string
+	tx    qvapay.Transaction
+}
+
+// NewEngine returns an Engine without apps.
+func NewEngine(opts EngineOptions) *Engine {
+	if opts.BaseURL == "" {
+		opts.BaseURL = qvapay.BaseURL
+	}
+	if opts.PerPage <= 0 {
+		opts.PerPage = DefaultPerPage
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Engine{
+		opts: opts,
+		apps: map[string]*appState{},
+		txs:  map[string]*txState{},
+	}
+}
+
+// AddApp registers an app, or replaces the settings of an app with the same
+// ID and keeps its transactions.
+func (e *Engine) AddApp(app App) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if a, ok := e.apps[app.ID]; ok {
+		a.App = app
+		return
+	}
+	e.apps[app.ID] = &appState{App: app, num: len(e.apps) + 1}
+}
+
+// App returns the app with that id.
+func (e *Engine) App(id string) (App, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	a, ok := e.apps[id]
+	if !ok {
+		return App{}, false
+	}
+	return a.App, true
+}
+
+// Apps returns every app.
+func (e *Engine) Apps() []App {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	apps := make([]App, len(e.apps))
+	for _, a := range e.apps {
+		apps[a.num-1] = a.App
+	}
+	return apps
+}
+
+// AddTransaction adds an existing transaction to an app, e.g. seed data.
+// The uuid, status and dates default to a new pending transaction.
+func (e *Engine) AddTransaction(appID string, tx qvapay.Transaction) (qvapay.Transaction, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	a, ok := e.apps[appID]
+	if !ok {
+		return qvapay.Transaction{}, e.notFound("app", appID)
+	}
+	if tx.ID == "" {
+		tx.ID = newUUID()
+	}
+	if _, ok := e.txs[tx.ID]; ok {
+		return qvapay.Transaction{}, fmt.Errorf("qvapaytest: duplicate transaction %s", tx.ID)
+	}
+	if tx.Status == "" {
+		tx.Status = qvapay.StatusPending
+	}
+	if tx.CreatedAt.IsZero() {
+		tx.CreatedAt = qvapay.Timestamp{Time: e.opts.Now().UTC()}
+	}
+	if tx.UpdatedAt.IsZero() {
+		tx.UpdatedAt = tx.CreatedAt
+	}
+	tx.AppID = a.num
+	if tx.UserID == 0 {
+		tx.UserID = a.UserID
+	}
+	e.txs[tx.ID] = &txState{appID: appID, tx: tx}
+	a.txs = append(a.txs, tx.ID)
+	return tx, nil
+}
+
+// AddOffers adds P2P offers, the uuid of the offers defaults to a new one.
+func (e *Engine) AddOffers(offers ...qvapay.Offer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, o := range offers {
+		if o.ID == "" {
+			o.ID = newUUID()
+		}
+		e.offers = append(e.offers, o)
+	}
+}
+
+// Authenticate checks the credentials of an app.
+func (e *Engine) Authenticate(appID, secret string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if a, ok := e.apps[appID]; ok && a.Secret == secret && secret != "" {
+		return nil
+	}
+	return e.statusError(http.StatusUnauthorized, "", "Unauthorized")
+}
+
+// Info returns the info of an app, like GET /v1/info.
+func (e *Engine) Info(appID string) (*qvapay.AppInfoResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	a, ok := e.apps[appID]
+	if !ok {
+		return nil, e.notFound("app", appID)
+	}
+	info := appInfo(a.App)
+	return &info, nil
+}
+
+// CreateInvoice creates a pending transaction, like GET /v1/create_invoice.
+func (e *Engine) CreateInvoice(appID string, amount qvapay.Amount, description, remoteID string) (*qvapay.InvoiceResponse, error) {
+	if amount.Sign() <= 0 {
+		return nil, e.statusError(http.StatusUnprocessableEntity, qvapay.RouteInvoice, "The amount must be greater than 0.")
+	}
+	tx, err := e.AddTransaction(appID, qvapay.Transaction{
+		Amount:      amount,
+		Description: description,
+		RemoteID:    remoteID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	payURL := e.opts.BaseURL + "/pay/" + tx.ID
+	return &qvapay.InvoiceResponse{
+		AppID:           appID,
+		Amount:          amount,
+		Desciption:      description,
+		RemoteID:        remoteID,
+		Signed:          remoteID,
+		TransactionUUID: tx.ID,
+		URL:             payURL,
+		SignedUrl:       payURL + "?signed=" + remoteID,
+	}, nil
+}
+
+// Transactions returns a page of the transactions of an app, newest first,
+// like GET /v1/transactions. The filters of the query apply before paging.
+func (e *Engine) Transactions(appID string, query qvapay.APIQueryParams) (*qvapay.TransactionsResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	a, ok := e.apps[appID]
+	if !ok {
+		return nil, e.notFound("app", appID)
+	}
+	page := max(query.Page, 1)
+	query.Page = 0
+	var txs []qvapay.Transaction
+	for i := len(a.txs) - 1; i >= 0; i-- {
+		if tx := e.txs[a.txs[i]].tx; query.Match(tx) {
+			txs = append(txs, tx)
+		}
+	}
+
+	path := fmt.Sprintf("%s/%s/%s", e.opts.BaseURL, qvapay.ApiVersion, qvapay.RouteTxs)
+	p := paginate(len(txs), page, e.opts.PerPage, path)
+	res := &qvapay.TransactionsResponse{
+		CurrentPage:  p.current,
+		Data:         txs[p.from:p.to],
+		FristPageURL: p.first,
+		LastPage:     p.last,
+		LastPageURL:  p.lastURL,
+		NextPageURL:  p.next,
+		Path:         path,
+		PerPage:      e.opts.PerPage,
+		PrevPageURL:  p.prev,
+		Total:        len(txs),
+	}
+	if p.to > p.from {
+		res.From, res.To = p.from+1, p.to
+	}
+	return res, nil
+}
+
+// Transaction returns a transaction of an app, like GET /v1/transaction/{id}.
+func (e *Engine) Transaction(appID, id string) (*qvapay.TransactionReponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	t, ok := e.txs[id]
+	if !ok || t.appID != appID {
+		return nil, e.notFound("transaction", id)
+	}
+	a := e.apps[appID]
+	info := appInfo(a.App)
+	tx := t.tx
+	return &qvapay.TransactionReponse{
+		ID:           tx.ID,
+		UserID:       tx.UserID,
+		AppID:        tx.AppID,
+		Amount:       tx.Amount,
+		Description:  tx.Description,
+		RemoteID:     tx.RemoteID,
+		Status:       tx.Status,
+		PaidByUserID: tx.PaidByUserID,
+		Signed:       tx.Signed,
+		CreatedAt:    tx.CreatedAt,
+		UpdatedAt:    tx.UpdatedAt,
+		App: qvapay.App{
+			UserID:   info.UserID,
+			Name:     info.Name,
+			URL:      info.URL,
+			Desc:     info.Desc,
+			Callback: info.Callback,
+			Logo:     info.Logo,
+			Uuid:     info.Uuid,
+			Active:   info.Active,
+			Enabled:  info.Enabled,
+		},
+	}, nil
+}
+
+// Balance returns the balance of an app, like GET /v1/balance.
+func (e *Engine) Balance(appID string) (qvapay.Amount, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	a, ok := e.apps[appID]
+	if !ok {
+		return qvapay.Amount{}, e.notFound("app", appID)
+	}
+	return a.Balance, nil
+}
+
+// Offers returns a page of the P2P offers, like GET /p2p/index.
+func (e *Engine) Offers(query qvapay.QueryParams) *qvapay.OffersPage {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	path := e.opts.BaseURL + "/" + qvapay.RouteOffers
+	p := paginate(len(e.offers), max(query.Page, 1), e.opts.PerPage, path)
+	res := &qvapay.OffersPage{
+		CurrentPage:  p.current,
+		Data:         append([]qvapay.Offer(nil), e.offers[p.from:p.to]...),
+		FirstPageURL: p.first,
+		LastPage:     p.last,
+		LastPageURL:  p.lastURL,
+		NextPageURL:  p.next,
+		Path:         path,
+		PerPage:      e.opts.PerPage,
+		PrevPageURL:  p.prev,
+		Total:        len(e.offers),
+	}
+	if p.to > p.from {
+		res.From, res.To = p.from+1, p.to
+	}
+	return res
+}
+
+// Pay marks a transaction paid and credits its app.
+func (e *Engine) Pay(ctx context.Context, id string) (qvapay.Transaction, error) {
+	return e.SetStatus(ctx, id, qvapay.StatusPaid)
+}
+
+// Cancel marks a transaction cancelled.
+func (e *Engine) Cancel(ctx context.Context, id string) (qvapay.Transaction, error) {
+	return e.SetStatus(ctx, id, qvapay.StatusCancelled)
+}
+
+// SetStatus changes the status of a transaction, crediting its app when it
+// becomes paid, and sends the app callback. The status is changed even when
+// the callback fails, the callback error is returned. Setting the current
+// status again does nothing, e.g. paying twice credits the app once.
+func (e *Engine) SetStatus(ctx context.Context, id string, status qvapay.TransactionStatus) (qvapay.Transaction, error) {
+	tx, callback, changed, err := e.setStatus(id, status)
+	if err != nil || !changed || callback == "" {
+		return tx, err
+	}
+	return tx, e.notify(ctx, callback, tx)
+}
+
+// setStatus changes the status of a transaction and returns the callback of
+// its app. Nothing changes when the status is the same or the balance of
+// the app would overflow.
+func (e *Engine) setStatus(id string, status qvapay.TransactionStatus) (tx qvapay.Transaction, callback string, changed bool, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	t, ok := e.txs[id]
+	if !ok {
+		return qvapay.Transaction{}, "", false, e.notFound("transaction", id)
+	}
+	if t.tx.Status == status {
+		return t.tx, "", false, nil
+	}
+	if err := qvapay.ValidateTransition(t.tx.Status, status); err != nil {
+		return qvapay.Transaction{}, "", false, err
+	}
+	a := e.apps[t.appID]
+	balance := a.Balance
+	if status.IsPaid() {
+		if balance, err = balance.CheckedAdd(t.tx.Amount); err != nil {
+			return qvapay.Transaction{}, "", false, fmt.Errorf("qvapaytest: crediting app %s: %w", a.ID, err)
+		}
+	}
+	a.Balance = balance
+	t.tx.Status = status
+	t.tx.UpdatedAt = qvapay.Timestamp{Time: e.opts.Now().UTC()}
+	return t.tx, a.Callback, true, nil
+}
+
+// notify posts the app callback of a change of status.
+func (e *Engine) notify(ctx context.Context, callback string, tx qvapay.Transaction) error {
+	body, err := json.Marshal(map[string]any{
+		"transaction_uuid": tx.ID,
+		"remote_id":        tx.RemoteID,
+		"amount":           tx.Amount,
+		"status":           tx.Status,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callback, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("qvapaytest: callback of %s: %w", tx.ID, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.opts.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("qvapaytest: callback of %s: %w", tx.ID, err)
+	}
+	defer qvapay.DrainBody(resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("qvapaytest: callback of %s: status %d", tx.ID, resp.StatusCode)
+	}
+	return nil
+}
+
+// statusError is the error the API answers, the Server answers its status
+// and message and the Fake returns it as is.
+func (e *Engine) statusError(status int, route, message string) *qvapay.StatusError {
+	return &qvapay.StatusError{
+		StatusCode: status,
+		Body:       errorBody(message),
+		URL:        strings.TrimSuffix(fmt.Sprintf("%s/%s/%s", e.opts.BaseURL, qvapay.ApiVersion, route), "/"),
+		Message:    message,
+	}
+}
+
+func (e *Engine) notFound(kind, id string) *qvapay.StatusError {
+	route := ""
+	if kind == "transaction" {
+		route = qvapay.RouteTx + "/" + id
+	}
+	return e.statusError(http.StatusNotFound, route, fmt.Sprintf("%s %s not found", kind, id))
+}
+
+func errorBody(message string) string {
+	body, _ := json.Marshal(map[string]string{"error": message})
+	return string(body)
+}
+
+func appInfo(a App) qvapay.AppInfoResponse {
+	return qvapay.AppInfoResponse{
+		UserID:   a.UserID,
+		Name:     a.Name,
+		URL:      a.URL,
+		Desc:     a.Desc,
+		Callback: a.Callback,
+		Logo:     a.Logo,
+		Uuid:     a.ID,
+		Active:   1,
+		Enabled:  1,
+	}
+}
+
+// page is the position of a page in a list.
+type page struct {
+	current, last, from, to    int
+	first, lastURL, next, prev string
+}
+
+func paginate(total, current, perPage int, path string) page {
+	p := page{current: current, last: max((total+perPage-1)/perPage, 1)}
+	p.from = min((current-1)*perPage, total)
+	p.to = min(p.from+perPage, total)
+	pageURL := func(n int) string { return path + "?page=" + strconv.Itoa(n) }
+	p.first, p.lastURL = pageURL(1), pageURL(p.last)
+	if current < p.last {
+		p.next = pageURL(current + 1)
+	}
+	if current > 1 {
+		p.prev = pageURL(current - 1)
+	}
+	return p
+}
+
+// newUUID returns a random version 4 uuid.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,245 @@
+package qvapaytest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/kenriortega/qvapay-go"
+)
+
+// FakeCall is a call made to a Fake, with the params and result types of
+// qvapay.Call, and the error returned.
+type FakeCall struct {
+	qvapay.Call
+	Err error
+}
+
+// Fake is a qvapay.IQvaPay answering from an Engine as an app, without
+// HTTP. Invoices become pending transactions, paying them with Engine().Pay
+// credits the app balance, and every call is recorded.
+//
+//	fake := qvapaytest.NewFake(nil, "app")
+//	fake.FailNext(qvapay.OpGetBalance, &qvapay.StatusError{StatusCode: http.StatusServiceUnavailable})
+//	svc := NewCheckout(fake)
+type Fake struct {
+	engine *Engine
+	appID  string
+
+	mu     sync.Mutex
+	calls  []FakeCall
+	next   map[string][]error
+	always map[string]error
+}
+
+var _ qvapay.IQvaPay = (*Fake)(nil)
+
+// NewFake returns a Fake calling engine as the app appID, which is added to
+// the engine when missing. A nil engine is a new one.
+func NewFake(engine *Engine, appID string) *Fake {
+	if engine == nil {
+		engine = NewEngine(EngineOptions{})
+	}
+	if _, ok := engine.App(appID); !ok {
+		engine.AddApp(App{ID: appID})
+	}
+	return &Fake{
+		engine: engine,
+		appID:  appID,
+		next:   map[string][]error{},
+		always: map[string]error{},
+	}
+}
+
+// Engine returns the engine of the fake, e.g. to pay its invoices.
+func (f *Fake) Engine() *Engine {
+	return f.engine
+}
+
+// FailNext makes the next calls of operation (qvapay.OpGetInfo,
+// qvapay.OpOffers...) return errs, one per call.
+func (f *Fake) FailNext(operation string, errs ...error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.next[operation] = append(f.next[operation], errs...)
+}
+
+// FailAlways makes every call of operation return err once the errors of
+// FailNext are used, a nil err stops it.
+func (f *Fake) FailAlways(operation string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.always, operation)
+		return
+	}
+	f.always[operation] = err
+}
+
+// Calls returns the calls made so far.
+func (f *Fake) Calls() []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeCall(nil), f.calls...)
+}
+
+// CallsTo returns the calls made so far to operation.
+func (f *Fake) CallsTo(operation string) []FakeCall {
+	var calls []FakeCall
+	for _, c := range f.Calls() {
+		if c.Operation == operation {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the calls and the scripted errors.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+	f.next = map[string][]error{}
+	f.always = map[string]error{}
+}
+
+// do runs a call through the scripted errors, then fn, and records it.
+func (f *Fake) do(ctx context.Context, call *qvapay.Call, fn func() (any, error)) error {
+	err := ctx.Err()
+	if err == nil {
+		err = f.scripted(call.Operation)
+	}
+	if err == nil {
+		call.Result, err = fn()
+	}
+	f.mu.Lock()
+	f.calls = append(f.calls, FakeCall{Call: *call, Err: err})
+	f.mu.Unlock()
+	return err
+}
+
+func (f *Fake) scripted(operation string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if errs := f.next[operation]; len(errs) > 0 {
+		f.next[operation] = errs[1:]
+		return errs[0]
+	}
+	return f.always[operation]
+}
+
+// GetInfo returns the info of the app.
+func (f *Fake) GetInfo(ctx context.Context) (*qvapay.AppInfoResponse, error) {
+	var info *qvapay.AppInfoResponse
+	call := &qvapay.Call{Operation: qvapay.OpGetInfo, Route: qvapay.RouteInfo}
+	err := f.do(ctx, call, func() (any, error) {
+		var err error
+		info, err = f.engine.Info(f.appID)
+		return info, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// CreateInvoice creates a pending transaction of the app.
+func (f *Fake) CreateInvoice(ctx context.Context, amount qvapay.Amount, description, remoteID string) (*qvapay.InvoiceResponse, error) {
+	var invoice *qvapay.InvoiceResponse
+	call := &qvapay.Call{
+		Operation: qvapay.OpCreateInvoice,
+		Route:     qvapay.RouteInvoice,
+		Params: &qvapay.CreateInvoiceParams{
+			Amount:      amount,
+			Description: description,
+			RemoteID:    remoteID,
+		},
+	}
+	err := f.do(ctx, call, func() (any, error) {
+		var err error
+		invoice, err = f.engine.CreateInvoice(f.appID, amount, description, remoteID)
+		return invoice, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// GetTransactions returns a page of the transactions of the app, newest
+// first, paged by status and remote_id like the API, then filtered like the
+// client by every filter of query.
+func (f *Fake) GetTransactions(ctx context.Context, query qvapay.APIQueryParams) (*qvapay.TransactionsResponse, error) {
+	var page *qvapay.TransactionsResponse
+	call := &qvapay.Call{Operation: qvapay.OpGetTransactions, Route: qvapay.RouteTxs, Params: &query}
+	err := f.do(ctx, call, func() (any, error) {
+		var err error
+		page, err = f.engine.Transactions(f.appID, qvapay.APIQueryParams{
+			Page:     query.Page,
+			Status:   query.Status,
+			RemoteID: query.RemoteID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		kept := page.Data[:0]
+		for _, tx := range page.Data {
+			if query.Match(tx) {
+				kept = append(kept, tx)
+			}
+		}
+		page.Data = kept
+		return page, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// GetTransaction returns a transaction of the app.
+func (f *Fake) GetTransaction(ctx context.Context, id string) (*qvapay.TransactionReponse, error) {
+	var tx *qvapay.TransactionReponse
+	call := &qvapay.Call{
+		Operation: qvapay.OpGetTransaction,
+		Route:     qvapay.RouteTx,
+		Params:    &qvapay.GetTransactionParams{ID: id},
+	}
+	err := f.do(ctx, call, func() (any, error) {
+		var err error
+		tx, err = f.engine.Transaction(f.appID, id)
+		return tx, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// GetBalance returns the balance of the app.
+func (f *Fake) GetBalance(ctx context.Context) (qvapay.Amount, error) {
+	var balance qvapay.Amount
+	call := &qvapay.Call{Operation: qvapay.OpGetBalance, Route: qvapay.RouteBalance}
+	err := f.do(ctx, call, func() (any, error) {
+		var err error
+		balance, err = f.engine.Balance(f.appID)
+		return &balance, err
+	})
+	if err != nil {
+		return qvapay.Amount{}, err
+	}
+	return balance, nil
+}
+
+// Offers returns a page of the P2P offers.
+func (f *Fake) Offers(ctx context.Context, query qvapay.QueryParams) (*qvapay.OffersPage, error) {
+	var page *qvapay.OffersPage
+	call := &qvapay.Call{Operation: qvapay.OpOffers, Route: qvapay.RouteOffers, Params: &query}
+	err := f.do(ctx, call, func() (any, error) {
+		page = f.engine.Offers(query)
+		return page, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
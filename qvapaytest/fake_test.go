@@ -0,0 +1,91 @@
+package qvapaytest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/kenriortega/qvapay-go/qvapaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Fake_Invoice_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	fake := qvapaytest.NewFake(nil, appID)
+
+	invoice, err := fake.CreateInvoice(ctx, qvapay.MustParseAmount("25.60"), "Enanitos verdes", "BRID56568989")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = fake.Engine().Pay(ctx, invoice.TransactionUUID)
+	assert.NoError(t, err)
+
+	tx, err := fake.GetTransaction(ctx, invoice.TransactionUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, qvapay.StatusPaid, tx.Status)
+	balance, err := fake.GetBalance(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, qvapay.MustParseAmount("25.60"), balance)
+
+	_, err = fake.GetTransaction(ctx, "missing")
+	assert.True(t, errors.Is(err, qvapay.ErrNotFound))
+
+	calls := fake.CallsTo(qvapay.OpCreateInvoice)
+	if assert.Len(t, calls, 1) {
+		params := calls[0].Params.(*qvapay.CreateInvoiceParams)
+		assert.Equal(t, "BRID56568989", params.RemoteID)
+		assert.Equal(t, invoice, calls[0].Result)
+	}
+	assert.Len(t, fake.Calls(), 4)
+}
+
+func Test_Fake_Pages(t *testing.T) {
+	ctx := context.Background()
+	fake := qvapaytest.NewFake(nil, appID)
+	for i := 0; i < 40; i++ {
+		_, err := fake.CreateInvoice(ctx, qvapay.MustParseAmount("1"), "", fmt.Sprintf("ORD-%d", i))
+		assert.NoError(t, err)
+	}
+	page, err := fake.GetTransactions(ctx, qvapay.APIQueryParams{})
+	assert.NoError(t, err)
+	assert.Len(t, page.Data, qvapaytest.DefaultPerPage)
+	assert.Equal(t, 3, page.LastPage)
+	assert.Equal(t, "ORD-39", page.Data[0].RemoteID)
+
+	txs, err := qvapay.ListTransactions(ctx, fake, qvapay.APIQueryParams{RemoteIDPrefix: "ORD-1"})
+	assert.NoError(t, err)
+	assert.Len(t, txs, 11) // ORD-1 and ORD-10 to ORD-19
+	assert.Len(t, fake.CallsTo(qvapay.OpGetTransactions), 4)
+}
+
+func Test_Fake_Scripted_Errors(t *testing.T) {
+	ctx := context.Background()
+	fake := qvapaytest.NewFake(nil, appID)
+	unavailable := &qvapay.StatusError{StatusCode: http.StatusServiceUnavailable}
+	fake.FailNext(qvapay.OpGetBalance, unavailable, qvapay.ErrRateLimited)
+
+	_, err := fake.GetBalance(ctx)
+	assert.True(t, errors.Is(err, qvapay.ErrServerError))
+	_, err = fake.GetBalance(ctx)
+	assert.True(t, errors.Is(err, qvapay.ErrRateLimited))
+	_, err = fake.GetBalance(ctx)
+	assert.NoError(t, err)
+
+	fake.FailAlways(qvapay.OpGetInfo, qvapay.ErrUnauthorized)
+	for i := 0; i < 2; i++ {
+		_, err = fake.GetInfo(ctx)
+		assert.True(t, errors.Is(err, qvapay.ErrUnauthorized))
+	}
+	fake.FailAlways(qvapay.OpGetInfo, nil)
+	_, err = fake.GetInfo(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, qvapay.ErrUnauthorized, fake.CallsTo(qvapay.OpGetInfo)[0].Err)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = fake.Offers(canceled, qvapay.QueryParams{})
+	assert.True(t, errors.Is(err, context.Canceled))
+}
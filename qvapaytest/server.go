@@ -0,0 +1,264 @@
+// Package qvapaytest provides a fake QvaPay for tests: a stateful Engine
+// and a Server that serves it with the routes of the API, or a Fake calling
+// it without HTTP. A Recorder and a Replayer capture real traffic once and
+// serve it back offline.
+//
+//	s := qvapaytest.NewServer(nil)
+//	defer s.Close()
+//	s.Engine().AddApp(qvapaytest.App{ID: "app", Secret: "secret", Callback: hook.URL})
+//	client := qvapay.NewPaymentAppClient(s.AppOptions("app"))
+//	invoice, _ := client.CreateInvoice(ctx, qvapay.MustParseAmount("25.60"), "Enanitos verdes", "BRID56568989")
+//	s.Engine().Pay(ctx, invoice.TransactionUUID)
+package qvapaytest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kenriortega/qvapay-go"
+)
+
+// Fault is an error injected in the responses of a Handler.
+type Fault struct {
+	// Route limits the fault to a route (qvapay.RouteInfo,
+	// qvapay.RouteOffers...), every route when empty.
+	Route string
+	// Latency delays the response.
+	Latency time.Duration
+	// Status answers that status code instead of the route response.
+	Status int
+	// Malformed answers a truncated JSON body.
+	Malformed bool
+	// Times is how many responses get the fault, every response when 0.
+	Times int
+}
+
+// Handler serves an Engine with the routes of the API.
+type Handler struct {
+	engine *Engine
+	mux    *http.ServeMux
+
+	mu     sync.Mutex
+	faults []*Fault
+}
+
+// NewHandler returns a Handler serving engine.
+func NewHandler(engine *Engine) *Handler {
+	h := &Handler{engine: engine, mux: http.NewServeMux()}
+	h.handle(qvapay.RouteInfo, h.info)
+	h.handle(qvapay.RouteInvoice, h.createInvoice)
+	h.handle(qvapay.RouteTxs, h.transactions)
+	h.handle(qvapay.RouteTx+"/{id}", h.transaction)
+	h.handle(qvapay.RouteBalance, h.balance)
+	h.mux.HandleFunc("/"+qvapay.RouteOffers, h.faulty(qvapay.RouteOffers, h.offers))
+	return h
+}
+
+// Engine returns the engine served by h.
+func (h *Handler) Engine() *Engine {
+	return h.engine
+}
+
+// InjectFault adds a fault, the first fault matching a route applies.
+func (h *Handler) InjectFault(f Fault) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.faults = append(h.faults, &f)
+}
+
+// ClearFaults removes every fault.
+func (h *Handler) ClearFaults() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.faults = nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// appHandler answers a request authenticated as the app appID.
+type appHandler func(r *http.Request, appID string) (any, error)
+
+// handle serves an app route, the app credentials are read from the query,
+// the headers or a JSON body like the client sends them.
+func (h *Handler) handle(route string, fn appHandler) {
+	pattern := fmt.Sprintf("/%s/%s", qvapay.ApiVersion, route)
+	// the fault route is the client route, without the path params
+	faultRoute := route
+	if route == qvapay.RouteTx+"/{id}" {
+		faultRoute = qvapay.RouteTx
+	}
+	h.mux.HandleFunc(pattern, h.faulty(faultRoute, func(w http.ResponseWriter, r *http.Request) {
+		appID, secret := credentials(r)
+		if err := h.engine.Authenticate(appID, secret); err != nil {
+			writeError(w, err)
+			return
+		}
+		res, err := fn(r, appID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if balance, ok := res.(qvapay.Amount); ok {
+			// the API answers the balance as {"66.00"}
+			fmt.Fprintf(w, `{"%s"}`, balance)
+			return
+		}
+		writeJSON(w, http.StatusOK, res)
+	}))
+}
+
+// faulty applies the first fault matching route before calling next.
+func (h *Handler) faulty(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f, ok := h.fault(route)
+		if !ok {
+			next(w, r)
+			return
+		}
+		if f.Latency > 0 {
+			t := time.NewTimer(f.Latency)
+			select {
+			case <-t.C:
+			case <-r.Context().Done():
+				t.Stop()
+				return
+			}
+		}
+		switch {
+		case f.Status != 0:
+			writeJSON(w, f.Status, map[string]string{"error": http.StatusText(f.Status)})
+		case f.Malformed:
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"malformed": [`)
+		default:
+			next(w, r)
+		}
+	}
+}
+
+// fault returns the first fault matching route and counts its use.
+func (h *Handler) fault(route string) (Fault, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, f := range h.faults {
+		if f.Route != "" && f.Route != route {
+			continue
+		}
+		if f.Times > 0 {
+			if f.Times--; f.Times == 0 {
+				h.faults = append(h.faults[:i:i], h.faults[i+1:]...)
+			}
+		}
+		return *f, true
+	}
+	return Fault{}, false
+}
+
+func (h *Handler) info(_ *http.Request, appID string) (any, error) {
+	return h.engine.Info(appID)
+}
+
+func (h *Handler) createInvoice(r *http.Request, appID string) (any, error) {
+	q := r.URL.Query()
+	amount, err := qvapay.ParseAmount(q.Get("amount"))
+	if err != nil {
+		return nil, h.engine.statusError(http.StatusUnprocessableEntity, qvapay.RouteInvoice, "The amount field is invalid.")
+	}
+	return h.engine.CreateInvoice(appID, amount, q.Get("description"), q.Get("remote_id"))
+}
+
+func (h *Handler) transactions(r *http.Request, appID string) (any, error) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	return h.engine.Transactions(appID, qvapay.APIQueryParams{
+		Page:     page,
+		Status:   qvapay.ParseTransactionStatus(q.Get("status")),
+		RemoteID: q.Get("remote_id"),
+	})
+}
+
+func (h *Handler) transaction(r *http.Request, appID string) (any, error) {
+	return h.engine.Transaction(appID, r.PathValue("id"))
+}
+
+func (h *Handler) balance(_ *http.Request, appID string) (any, error) {
+	return h.engine.Balance(appID)
+}
+
+func (h *Handler) offers(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	writeJSON(w, http.StatusOK, h.engine.Offers(qvapay.QueryParams{Page: page}))
+}
+
+// credentials reads the app credentials of a request.
+func credentials(r *http.Request) (appID, secret string) {
+	q := r.URL.Query()
+	if q.Has("app_id") {
+		return q.Get("app_id"), q.Get("app_secret")
+	}
+	if id := r.Header.Get("app-id"); id != "" {
+		return id, r.Header.Get("app-secret")
+	}
+	var body struct {
+		AppID     string `json:"app_id"`
+		AppSecret string `json:"app_secret"`
+	}
+	if r.Body != nil {
+		json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&body)
+	}
+	return body.AppID, body.AppSecret
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var statusErr *qvapay.StatusError
+	if errors.As(err, &statusErr) {
+		writeJSON(w, statusErr.StatusCode, map[string]string{"error": statusErr.Message})
+		return
+	}
+	writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Server is an httptest.Server serving a Handler.
+type Server struct {
+	*httptest.Server
+	*Handler
+}
+
+// NewServer starts a Server serving engine, or a new Engine whose URLs are
+// the ones of the server when engine is nil. Close stops it.
+func NewServer(engine *Engine) *Server {
+	srv := httptest.NewUnstartedServer(nil)
+	if engine == nil {
+		engine = NewEngine(EngineOptions{BaseURL: "http://" + srv.Listener.Addr().String()})
+	}
+	h := NewHandler(engine)
+	srv.Config.Handler = h
+	srv.Start()
+	return &Server{Server: srv, Handler: h}
+}
+
+// AppOptions returns the client options to call the server as the app
+// appID.
+func (s *Server) AppOptions(appID string) qvapay.Options {
+	app, _ := s.engine.App(appID)
+	return qvapay.Options{
+		BaseURL:  s.URL,
+		AppID:    app.ID,
+		SecretID: app.Secret,
+	}
+}
@@ -0,0 +1,213 @@
+package qvapaytest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/kenriortega/qvapay-go/qvapaytest"
+	"github.com/kenriortega/qvapay-go/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	appID    = "c2ffb7c5-2c3f-4b8b-b0f4-0e7a0d1f2a3b"
+	secretID = "0Tz8hKj1mQ"
+)
+
+func newServer(t *testing.T, callback string) (*qvapaytest.Server, qvapay.PaymentAppClient) {
+	s := qvapaytest.NewServer(nil)
+	t.Cleanup(s.Close)
+	s.Engine().AddApp(qvapaytest.App{
+		ID:       appID,
+		Secret:   secretID,
+		Name:     "Enanitos",
+		Callback: callback,
+		Balance:  qvapay.MustParseAmount("10"),
+	})
+	return s, qvapay.NewPaymentAppClient(s.AppOptions(appID))
+}
+
+func Test_Invoice_Paid_With_Callback(t *testing.T) {
+	ctx := context.Background()
+	events := make(chan webhook.PaymentEvent, 1)
+	var client qvapay.PaymentAppClient
+	hook := httptest.NewServer(webhook.NewHandler(
+		webhook.Options{Confirmer: confirmer{&client}},
+		webhook.EventHandlerFunc(func(_ context.Context, e webhook.PaymentEvent) error {
+			events <- e
+			return nil
+		}),
+	))
+	defer hook.Close()
+	s, c := newServer(t, hook.URL)
+	client = c
+
+	info, err := client.GetInfo(ctx)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, "Enanitos", info.Name)
+
+	invoice, err := client.CreateInvoice(ctx, qvapay.MustParseAmount("25.60"), "Enanitos verdes", "BRID56568989")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	tx, err := client.GetTransaction(ctx, invoice.TransactionUUID)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, qvapay.StatusPending, tx.Status)
+	assert.Equal(t, "BRID56568989", tx.RemoteID)
+
+	_, err = s.Engine().Pay(ctx, invoice.TransactionUUID)
+	assert.NoError(t, err)
+	event := <-events
+	assert.True(t, event.Confirmed)
+	assert.Equal(t, qvapay.StatusPaid, event.Status)
+	assert.Equal(t, qvapay.MustParseAmount("25.60"), event.Amount)
+
+	balance, err := client.GetBalance(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, qvapay.MustParseAmount("35.60"), balance)
+
+	txs, err := client.GetTransactions(ctx, qvapay.APIQueryParams{Status: qvapay.StatusPaid})
+	assert.NoError(t, err)
+	if assert.Len(t, txs.Data, 1) {
+		assert.Equal(t, invoice.TransactionUUID, txs.Data[0].ID)
+	}
+
+	_, err = s.Engine().Cancel(ctx, invoice.TransactionUUID)
+	assert.True(t, errors.Is(err, qvapay.ErrInvalidTransition))
+}
+
+func Test_Pay_Twice_Credits_Once(t *testing.T) {
+	ctx := context.Background()
+	var callbacks atomic.Int32
+	hook := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		callbacks.Add(1)
+	}))
+	defer hook.Close()
+	s, client := newServer(t, hook.URL)
+
+	invoice, err := client.CreateInvoice(ctx, qvapay.MustParseAmount("10"), "", "ORD")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	for i := 0; i < 2; i++ {
+		tx, err := s.Engine().Pay(ctx, invoice.TransactionUUID)
+		assert.NoError(t, err)
+		assert.Equal(t, qvapay.StatusPaid, tx.Status)
+	}
+	balance, err := client.GetBalance(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, qvapay.MustParseAmount("20"), balance)
+	assert.Equal(t, int32(1), callbacks.Load())
+}
+
+func Test_Pay_Overflow_Keeps_The_Engine(t *testing.T) {
+	ctx := context.Background()
+	s, client := newServer(t, "")
+	s.Engine().AddApp(qvapaytest.App{ID: appID, Secret: secretID, Balance: qvapay.MustParseAmount("92233720368")})
+
+	invoice, err := client.CreateInvoice(ctx, qvapay.MustParseAmount("1"), "", "ORD")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = s.Engine().Pay(ctx, invoice.TransactionUUID)
+	assert.True(t, errors.Is(err, qvapay.ErrAmountOverflow))
+
+	tx, err := client.GetTransaction(ctx, invoice.TransactionUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, qvapay.StatusPending, tx.Status)
+	balance, err := client.GetBalance(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, qvapay.MustParseAmount("92233720368"), balance)
+}
+
+// confirmer confirms the callbacks with a client created after the hook.
+type confirmer struct {
+	client *qvapay.PaymentAppClient
+}
+
+func (c confirmer) GetTransaction(ctx context.Context, id string) (*qvapay.TransactionReponse, error) {
+	return (*c.client).GetTransaction(ctx, id)
+}
+
+func Test_Credentials(t *testing.T) {
+	s, _ := newServer(t, "")
+
+	opts := s.AppOptions(appID)
+	opts.SecretID = "wrong"
+	_, err := qvapay.NewPaymentAppClient(opts).GetInfo(context.Background())
+	assert.True(t, errors.Is(err, qvapay.ErrUnauthorized))
+
+	for _, mode := range []qvapay.CredentialsMode{qvapay.CredentialsInHeader, qvapay.CredentialsInBody} {
+		opts := s.AppOptions(appID)
+		opts.Credentials = mode
+		_, err := qvapay.NewPaymentAppClient(opts).GetBalance(context.Background())
+		assert.NoError(t, err)
+	}
+
+	_, err = qvapay.NewPaymentAppClient(s.AppOptions(appID)).GetTransaction(context.Background(), "missing")
+	assert.True(t, errors.Is(err, qvapay.ErrNotFound))
+}
+
+func Test_Pages(t *testing.T) {
+	s, client := newServer(t, "")
+	for i := 0; i < 20; i++ {
+		_, err := client.CreateInvoice(context.Background(), qvapay.MustParseAmount("1"), "", "ORD")
+		assert.NoError(t, err)
+	}
+	page, err := client.GetTransactions(context.Background(), qvapay.APIQueryParams{Page: 2})
+	assert.NoError(t, err)
+	assert.Len(t, page.Data, 5)
+	assert.Equal(t, 2, page.LastPage)
+	assert.Equal(t, 20, page.Total)
+
+	txs, err := qvapay.ListTransactions(context.Background(), client, qvapay.APIQueryParams{})
+	assert.NoError(t, err)
+	assert.Len(t, txs, 20)
+
+	s.Engine().AddOffers(qvapay.Offer{Type: qvapay.OfferBuy, Coin: "BTC", Amount: qvapay.MustParseAmount("50")})
+	offers, err := qvapay.NewQvaPay(qvapay.Options{BaseURL: s.URL}).Offers(context.Background(), qvapay.QueryParams{})
+	assert.NoError(t, err)
+	if assert.Len(t, offers.Data, 1) {
+		assert.Equal(t, "BTC", offers.Data[0].Coin)
+	}
+}
+
+func Test_Faults(t *testing.T) {
+	s, _ := newServer(t, "")
+	opts := s.AppOptions(appID)
+	opts.Retry = &qvapay.RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		RetryStatus: []int{http.StatusServiceUnavailable},
+	}
+	client := qvapay.NewPaymentAppClient(opts)
+
+	s.InjectFault(qvapaytest.Fault{Route: qvapay.RouteBalance, Status: http.StatusServiceUnavailable, Times: 2})
+	info := &qvapay.CallInfo{}
+	_, err := client.GetBalance(qvapay.WithCallInfo(context.Background(), info))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, info.Attempts)
+
+	s.InjectFault(qvapaytest.Fault{Route: qvapay.RouteInfo, Malformed: true, Times: 1})
+	_, err = client.GetInfo(context.Background())
+	assert.True(t, errors.Is(err, qvapay.ErrDecode))
+	_, err = client.GetInfo(context.Background())
+	assert.NoError(t, err)
+
+	s.InjectFault(qvapaytest.Fault{Latency: 200 * time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.GetInfo(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	s.ClearFaults()
+}
@@ -0,0 +1,131 @@
+package qvapay
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket shared by every call made through a client.
+// The same limiter can be set on several clients that use the same
+// credentials, so they share a single budget.
+type RateLimiter struct {
+	mu          sync.Mutex
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// NewRateLimiter returns a limiter that allows rps calls per second with
+// bursts of up to burst calls. A rps lower or equal to zero disables the
+// bucket, only the pauses requested by the server are applied.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a call is allowed. It fails right away, without waiting,
+// when the required wait goes beyond the ctx deadline.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	wait, reserved := l.reserve(time.Now())
+	if wait <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		l.cancel(reserved)
+		return fmt.Errorf("%w: waiting %s exceeds the context deadline", ErrRateLimited, wait)
+	}
+	if err := sleep(ctx, wait); err != nil {
+		l.cancel(reserved)
+		return err
+	}
+	return nil
+}
+
+// PauseUntil stops every call until t, it's used when the server asks to
+// slow down.
+func (l *RateLimiter) PauseUntil(t time.Time) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if t.After(l.pausedUntil) {
+		l.pausedUntil = t
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait to use it.
+func (l *RateLimiter) reserve(now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var wait time.Duration
+	reserved := false
+	if l.rate > 0 {
+		elapsed := now.Sub(l.last).Seconds()
+		l.tokens = math.Min(l.burst, l.tokens+elapsed*l.rate)
+		l.last = now
+		l.tokens--
+		reserved = true
+		if l.tokens < 0 {
+			wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+		}
+	}
+	if pause := l.pausedUntil.Sub(now); pause > wait {
+		wait = pause
+	}
+	return wait, reserved
+}
+
+func (l *RateLimiter) cancel(reserved bool) {
+	if !reserved {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = math.Min(l.burst, l.tokens+1)
+}
+
+// retryAfter returns how long the server asked to wait before the next call,
+// based on the Retry-After header or on exhausted rate limit headers.
+func retryAfter(h http.Header, now time.Time) time.Duration {
+	if h == nil {
+		return 0
+	}
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return t.Sub(now)
+		}
+	}
+	if h.Get("X-RateLimit-Remaining") != "0" {
+		return 0
+	}
+	reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	// the reset is either an epoch timestamp or a number of seconds
+	if reset > 1e9 {
+		return time.Unix(reset, 0).Sub(now)
+	}
+	return time.Duration(reset) * time.Second
+}
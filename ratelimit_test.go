@@ -0,0 +1,62 @@
+package qvapay_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RateLimiter_Wait_Bounded_By_Deadline(t *testing.T) {
+	limiter := qvapay.NewRateLimiter(1, 1)
+	assert.NoError(t, limiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := limiter.Wait(ctx)
+	assert.True(t, errors.Is(err, qvapay.ErrRateLimited))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func Test_RateLimiter_Honours_Retry_After(t *testing.T) {
+	var calls int32
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte(`{"66.0"}`))
+		}),
+	)
+	defer s.Close()
+
+	limiter := qvapay.NewRateLimiter(100, 10)
+	opts := qvapay.Options{
+		BaseURL:     s.URL,
+		AppID:       appID,
+		SecretID:    secretID,
+		RateLimiter: limiter,
+	}
+	first := qvapay.NewPaymentAppClient(opts)
+	_, err := first.GetBalance(context.Background())
+	assert.True(t, errors.Is(err, qvapay.ErrRateLimited))
+
+	// a second client sharing the limiter waits for the pause
+	second := qvapay.NewPaymentAppClient(opts)
+	start := time.Now()
+	balance, err := second.GetBalance(context.Background())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, qvapay.MustParseAmount("66.0"), balance)
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+}
@@ -0,0 +1,140 @@
+// Package reconcile checks the orders of a local database against the
+// QvaPay transactions of the app, matching them on Transaction.RemoteID.
+//
+//	report, err := reconcile.New(client, orders, reconcile.Options{
+//		Query: qvapay.APIQueryParams{RemoteIDPrefix: "ORD-"},
+//	}).Run(ctx)
+//	if err == nil && !report.OK() {
+//		report.WriteCSV(os.Stdout)
+//	}
+package reconcile
+
+import (
+	"context"
+	"sort"
+
+	"github.com/kenriortega/qvapay-go"
+)
+
+// Order is a local order expected to be paid by a single QvaPay transaction.
+type Order struct {
+	// RemoteID is the remote_id sent to CreateInvoice.
+	RemoteID string `json:"remote_id"`
+	// Amount is the expected amount of the transaction.
+	Amount qvapay.Amount `json:"amount"`
+	// Status is the expected status of the transaction, StatusPaid when
+	// empty.
+	Status qvapay.TransactionStatus `json:"status,omitempty"`
+}
+
+func (o Order) expectedStatus() qvapay.TransactionStatus {
+	if o.Status == "" {
+		return qvapay.StatusPaid
+	}
+	return o.Status
+}
+
+// OrderSource lists the orders to reconcile.
+type OrderSource interface {
+	Orders(ctx context.Context) ([]Order, error)
+}
+
+// OrderSourceFunc is a function that implements OrderSource.
+type OrderSourceFunc func(ctx context.Context) ([]Order, error)
+
+func (f OrderSourceFunc) Orders(ctx context.Context) ([]Order, error) {
+	return f(ctx)
+}
+
+// Orders is a fixed list of orders that implements OrderSource.
+type Orders []Order
+
+func (o Orders) Orders(context.Context) ([]Order, error) {
+	return o, nil
+}
+
+// Options configures a Reconciler.
+type Options struct {
+	// Query narrows the transactions walked, e.g. the period and the
+	// remote_id prefix of the orders. The transactions out of it are
+	// neither matched nor reported as unknown.
+	Query qvapay.APIQueryParams
+	// Iterator configures the walk of the transaction pages.
+	Iterator qvapay.IteratorOptions
+}
+
+// Reconciler matches the orders of an OrderSource with the transactions.
+type Reconciler struct {
+	api    qvapay.TransactionLister
+	orders OrderSource
+	opts   Options
+}
+
+// New returns a Reconciler of the orders against the transactions of api.
+func New(api qvapay.TransactionLister, orders OrderSource, opts Options) *Reconciler {
+	return &Reconciler{api: api, orders: orders, opts: opts}
+}
+
+// Run walks every transaction of the query and reports how each order
+// matches them. Each order gets one entry, in the order of the source,
+// followed by the unknown transactions grouped by remote_id.
+func (r *Reconciler) Run(ctx context.Context) (*Report, error) {
+	orders, err := r.orders.Orders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byRemoteID := map[string][]qvapay.Transaction{}
+	for tx, err := range qvapay.AllTransactions(ctx, r.api, r.opts.Query, r.opts.Iterator) {
+		if err != nil {
+			return nil, err
+		}
+		byRemoteID[tx.RemoteID] = append(byRemoteID[tx.RemoteID], tx)
+	}
+
+	report := &Report{}
+	seen := map[string]bool{}
+	for _, order := range orders {
+		if seen[order.RemoteID] {
+			report.add(Entry{Kind: DuplicateOrder, RemoteID: order.RemoteID, Order: &order})
+			continue
+		}
+		seen[order.RemoteID] = true
+		report.add(match(order, byRemoteID[order.RemoteID]))
+	}
+
+	unknown := make([]string, 0)
+	for remoteID := range byRemoteID {
+		if !seen[remoteID] {
+			unknown = append(unknown, remoteID)
+		}
+	}
+	sort.Strings(unknown)
+	for _, remoteID := range unknown {
+		report.add(Entry{Kind: Unknown, RemoteID: remoteID, Transactions: byRemoteID[remoteID]})
+	}
+	return report, nil
+}
+
+// match classifies the transactions of an order.
+func match(order Order, txs []qvapay.Transaction) Entry {
+	entry := Entry{RemoteID: order.RemoteID, Order: &order, Transactions: txs}
+	var expected []qvapay.Transaction
+	for _, tx := range txs {
+		if tx.Status == order.expectedStatus() {
+			expected = append(expected, tx)
+		}
+	}
+	switch {
+	case len(txs) == 0:
+		entry.Kind = Missing
+	case len(expected) > 1:
+		entry.Kind = Duplicate
+	case len(expected) == 0:
+		entry.Kind = StatusMismatch
+	case !expected[0].Amount.Equal(order.Amount):
+		entry.Kind = AmountMismatch
+	default:
+		entry.Kind = Matched
+	}
+	return entry
+}
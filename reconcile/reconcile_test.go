@@ -0,0 +1,122 @@
+package reconcile_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/kenriortega/qvapay-go/reconcile"
+	"github.com/stretchr/testify/assert"
+)
+
+var pages = map[string]string{
+	"1": `{
+		"current_page": 1,
+		"data": [
+			{"uuid": "tx-1", "amount": "25.60", "remote_id": "ORD-1", "status": "paid"},
+			{"uuid": "tx-2", "amount": "10.00", "remote_id": "ORD-2", "status": "paid"},
+			{"uuid": "tx-3", "amount": "5.00", "remote_id": "ORD-3", "status": "paid"}
+		],
+		"last_page": 2,
+		"next_page_url": "http://qvapay.com/api/v1/transactions?page=2"
+	}`,
+	"2": `{
+		"current_page": 2,
+		"data": [
+			{"uuid": "tx-4", "amount": "5.00", "remote_id": "ORD-3", "status": "paid"},
+			{"uuid": "tx-5", "amount": "7.00", "remote_id": "ORD-5", "status": "pending"},
+			{"uuid": "tx-6", "amount": "1.00", "remote_id": "ORD-9", "status": "paid"}
+		],
+		"last_page": 2,
+		"next_page_url": null
+	}`,
+}
+
+func newReconciler(t *testing.T, orders reconcile.Orders, opts reconcile.Options) *reconcile.Reconciler {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(pages[r.URL.Query().Get("page")]))
+		}),
+	)
+	t.Cleanup(s.Close)
+	client := qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL:  s.URL,
+			AppID:    "c2ffb7c5-2c3f-4b8b-b0f4-0e7a0d1f2a3b",
+			SecretID: "secret",
+		},
+	)
+	return reconcile.New(client, orders, opts)
+}
+
+func Test_Reconcile(t *testing.T) {
+	orders := reconcile.Orders{
+		{RemoteID: "ORD-1", Amount: qvapay.MustParseAmount("25.60")},
+		{RemoteID: "ORD-2", Amount: qvapay.MustParseAmount("12.00")},
+		{RemoteID: "ORD-3", Amount: qvapay.MustParseAmount("5.00")},
+		{RemoteID: "ORD-4", Amount: qvapay.MustParseAmount("3.00")},
+		{RemoteID: "ORD-5", Amount: qvapay.MustParseAmount("7.00")},
+		{RemoteID: "ORD-1", Amount: qvapay.MustParseAmount("25.60")},
+	}
+	report, err := newReconciler(t, orders, reconcile.Options{}).Run(context.Background())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	kinds := make([]reconcile.Kind, 0, len(report.Entries))
+	for _, e := range report.Entries {
+		kinds = append(kinds, e.Kind)
+	}
+	assert.Equal(t, []reconcile.Kind{
+		reconcile.Matched,
+		reconcile.AmountMismatch,
+		reconcile.Duplicate,
+		reconcile.Missing,
+		reconcile.StatusMismatch,
+		reconcile.DuplicateOrder,
+		reconcile.Unknown,
+	}, kinds)
+	assert.False(t, report.OK())
+	assert.Equal(t, 1, report.Summary[reconcile.Matched])
+	assert.Len(t, report.Filter(reconcile.Duplicate)[0].Transactions, 2)
+	assert.Equal(t, "ORD-9", report.Filter(reconcile.Unknown)[0].RemoteID)
+}
+
+func Test_Reconcile_Query_And_Export(t *testing.T) {
+	orders := reconcile.Orders{
+		{RemoteID: "ORD-1", Amount: qvapay.MustParseAmount("25.60")},
+		{RemoteID: "ORD-2", Amount: qvapay.MustParseAmount("10")},
+		{RemoteID: "ORD-5", Amount: qvapay.MustParseAmount("7"), Status: qvapay.StatusPending},
+	}
+	maxAmount := qvapay.MustParseAmount("20")
+	opts := reconcile.Options{Query: qvapay.APIQueryParams{MaxAmount: &maxAmount}}
+	report, err := newReconciler(t, orders, opts).Run(context.Background())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, map[reconcile.Kind]int{
+		reconcile.Missing: 1,
+		reconcile.Matched: 2,
+		reconcile.Unknown: 2,
+	}, report.Summary)
+
+	var buf bytes.Buffer
+	assert.NoError(t, report.WriteCSV(&buf))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 7) // header, 3 orders, 3 unknown transactions
+	assert.Equal(t, []string{"missing", "ORD-1", "25.60", "paid", "", "", "", ""}, rows[1])
+	assert.Equal(t, []string{"matched", "ORD-2", "10.00", "paid", "tx-2", "10.00", "paid", ""}, rows[2])
+
+	buf.Reset()
+	assert.NoError(t, report.WriteJSON(&buf))
+	var decoded reconcile.Report
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report.Summary, decoded.Summary)
+	assert.Equal(t, qvapay.MustParseAmount("7"), decoded.Entries[2].Order.Amount)
+}
@@ -0,0 +1,121 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+
+	"github.com/kenriortega/qvapay-go"
+)
+
+// Kind is the outcome of the reconciliation of an order or a transaction.
+type Kind string
+
+const (
+	// Matched is an order with a single transaction in the expected status
+	// and amount.
+	Matched Kind = "matched"
+	// Missing is an order without any transaction.
+	Missing Kind = "missing"
+	// AmountMismatch is an order whose transaction has another amount.
+	AmountMismatch Kind = "amount_mismatch"
+	// StatusMismatch is an order without transaction in the expected
+	// status, e.g. an invoice still pending.
+	StatusMismatch Kind = "status_mismatch"
+	// Duplicate is an order with several transactions in the expected
+	// status.
+	Duplicate Kind = "duplicate"
+	// DuplicateOrder is an order whose remote_id was already listed by the
+	// source.
+	DuplicateOrder Kind = "duplicate_order"
+	// Unknown are transactions whose remote_id isn't any order.
+	Unknown Kind = "unknown"
+)
+
+// Entry is the outcome of an order, or of the transactions of an unknown
+// remote_id.
+type Entry struct {
+	Kind         Kind                 `json:"kind"`
+	RemoteID     string               `json:"remote_id"`
+	Order        *Order               `json:"order,omitempty"`
+	Transactions []qvapay.Transaction `json:"transactions,omitempty"`
+}
+
+// Report is the result of a reconciliation.
+type Report struct {
+	Entries []Entry      `json:"entries"`
+	Summary map[Kind]int `json:"summary"`
+}
+
+func (r *Report) add(e Entry) {
+	if r.Summary == nil {
+		r.Summary = map[Kind]int{}
+	}
+	r.Entries = append(r.Entries, e)
+	r.Summary[e.Kind]++
+}
+
+// OK reports whether every order matched and no transaction is unknown.
+func (r *Report) OK() bool {
+	return r.Summary[Matched] == len(r.Entries)
+}
+
+// Filter returns the entries of the given kinds.
+func (r *Report) Filter(kinds ...Kind) []Entry {
+	var entries []Entry
+	for _, e := range r.Entries {
+		for _, k := range kinds {
+			if e.Kind == k {
+				entries = append(entries, e)
+				break
+			}
+		}
+	}
+	return entries
+}
+
+// WriteJSON writes the report as an indented JSON object.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+var csvHeader = []string{
+	"kind", "remote_id", "expected_amount", "expected_status",
+	"transaction_uuid", "amount", "status", "created_at",
+}
+
+// WriteCSV writes the report as CSV, one row per transaction of an entry
+// and a single row for the entries without transaction.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, e := range r.Entries {
+		order := make([]string, 2)
+		if e.Order != nil {
+			order = []string{e.Order.Amount.String(), e.Order.expectedStatus().String()}
+		}
+		prefix := append([]string{string(e.Kind), e.RemoteID}, order...)
+		if len(e.Transactions) == 0 {
+			if err := cw.Write(append(prefix, "", "", "", "")); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, tx := range e.Transactions {
+			createdAt := ""
+			if !tx.CreatedAt.IsZero() {
+				createdAt = tx.CreatedAt.Format(qvapay.TimestampLayout)
+			}
+			row := append(append([]string{}, prefix...), tx.ID, tx.Amount.String(), tx.Status.String(), createdAt)
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,58 @@
+package qvapay
+
+import "regexp"
+
+// Redacted replaces every masked value.
+const Redacted = "[REDACTED]"
+
+// DefaultRedactKeys are the keys always masked by a Redactor.
+var DefaultRedactKeys = []string{"app_secret", "app-secret", "secret"}
+
+var bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/=]+`)
+
+// Redactor masks credentials in debug dumps, error messages and logs: query
+// and form values, JSON fields and headers named after its keys, and bearer
+// tokens.
+type Redactor struct {
+	rules []redactRule
+}
+
+type redactRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// NewRedactor returns a Redactor for DefaultRedactKeys plus extraKeys.
+func NewRedactor(extraKeys ...string) *Redactor {
+	r := &Redactor{}
+	for _, key := range append(append([]string{}, DefaultRedactKeys...), extraKeys...) {
+		if key == "" {
+			continue
+		}
+		k := regexp.QuoteMeta(key)
+		r.rules = append(r.rules,
+			// query strings and form bodies: app_secret=value
+			redactRule{regexp.MustCompile(`(?i)((?:^|[?&\s])` + k + `=)[^&\s"]*`), "${1}" + Redacted},
+			// JSON fields: "secret": "value"
+			redactRule{regexp.MustCompile(`(?i)("` + k + `"\s*:\s*)(?:"(?:[^"\\]|\\.)*"|[^,}\s]+)`), `${1}"` + Redacted + `"`},
+			// headers: App-Secret: value
+			redactRule{regexp.MustCompile(`(?im)^(` + k + `:[ \t]*)[^\r\n]*`), "${1}" + Redacted},
+		)
+	}
+	return r
+}
+
+// Redact returns s with every secret masked, a nil Redactor only masks the
+// default keys.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		r = defaultRedactor
+	}
+	s = bearerPattern.ReplaceAllString(s, "${1}"+Redacted)
+	for _, rule := range r.rules {
+		s = rule.pattern.ReplaceAllString(s, rule.replacement)
+	}
+	return s
+}
+
+var defaultRedactor = NewRedactor()
@@ -0,0 +1,76 @@
+package qvapay_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Redactor(t *testing.T) {
+	r := qvapay.NewRedactor("token")
+	cases := map[string]string{
+		"GET /v1/info?app_id=1&app_secret=abc HTTP/1.1":     "GET /v1/info?app_id=1&app_secret=[REDACTED] HTTP/1.1",
+		`{"uuid":"1","secret":"123456987","active":1}`:      `{"uuid":"1","secret":"[REDACTED]","active":1}`,
+		"Authorization: Bearer eyJhbGciOi.x-y":              "Authorization: Bearer [REDACTED]",
+		"App-Secret: abc\r\nUser-Agent: qvapay-go":          "App-Secret: [REDACTED]\r\nUser-Agent: qvapay-go",
+		`{"token": 12345}`:                                  `{"token": "[REDACTED]"}`,
+		`{"description":"Enanitos verdes","remote_id":"1"}`: `{"description":"Enanitos verdes","remote_id":"1"}`,
+	}
+	for in, expected := range cases {
+		assert.Equal(t, expected, r.Redact(in))
+	}
+}
+
+func Test_Debug_Output_Is_Redacted(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte(`{"uuid":"123456789","secret":"123456987"}`))
+		}),
+	)
+	defer s.Close()
+
+	debug := &bytes.Buffer{}
+	client := qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL:  s.URL,
+			Debug:    debug,
+			AppID:    appID,
+			SecretID: secretID,
+		},
+	)
+	info, err := client.GetInfo(context.Background())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, "123456987", info.Secret)
+	assert.NotContains(t, debug.String(), secretID)
+	assert.NotContains(t, debug.String(), "123456987")
+}
+
+func Test_Credentials_In_Header(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.URL.Query().Get("app_secret"))
+			assert.Equal(t, appID, r.Header.Get("app-id"))
+			assert.Equal(t, secretID, r.Header.Get("app-secret"))
+			w.Write([]byte(`{"66.0"}`))
+		}),
+	)
+	defer s.Close()
+
+	client := qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL:     s.URL,
+			AppID:       appID,
+			SecretID:    secretID,
+			Credentials: qvapay.CredentialsInHeader,
+		},
+	)
+	_, err := client.GetBalance(context.Background())
+	assert.NoError(t, err)
+}
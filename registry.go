@@ -0,0 +1,90 @@
+package qvapay
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrUnknownBackend is returned by Open for a backend never registered.
+	ErrUnknownBackend = errors.New("qvapay: unknown backend")
+	// ErrUnsupported is returned by Open when the client of a backend
+	// doesn't implement the requested capability.
+	ErrUnsupported = errors.New("qvapay: unsupported capability")
+)
+
+// Backend builds a client from Options. The client implements the
+// capability interfaces (AppAPI, P2PAPI...) its credentials allow.
+type Backend func(opts Options) (any, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]Backend{
+		KindApp:    func(opts Options) (any, error) { return NewPaymentAppClient(opts), nil },
+		KindQvaPay: func(opts Options) (any, error) { return NewQvaPay(opts), nil },
+	}
+)
+
+// Register makes a backend available to Open under name, e.g. a sandbox or
+// a fake. It panics when name is already registered or backend is nil.
+func Register(name string, backend Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if backend == nil {
+		panic("qvapay: Register backend is nil")
+	}
+	if _, dup := backends[name]; dup {
+		panic("qvapay: Register called twice for backend " + name)
+	}
+	backends[name] = backend
+}
+
+// Backends returns the names of the registered backends, sorted.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open builds the client of the backend name and returns it as T, one of
+// the capability interfaces:
+//
+//	app, err := qvapay.Open[qvapay.AppAPI](qvapay.KindApp, opts)
+//	p2p, err := qvapay.Open[qvapay.P2PAPI](qvapay.KindQvaPay, opts)
+//
+// It fails with ErrUnsupported when the client doesn't implement T, e.g. an
+// AppAPI of the KindQvaPay backend, which has no credentials.
+func Open[T any](name string, opts Options) (T, error) {
+	var zero T
+	backendsMu.RLock()
+	backend, ok := backends[name]
+	backendsMu.RUnlock()
+	if !ok {
+		return zero, fmt.Errorf("%w %q", ErrUnknownBackend, name)
+	}
+	c, err := backend(opts)
+	if err != nil {
+		return zero, err
+	}
+	client, ok := c.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: backend %q doesn't implement %s", ErrUnsupported, name, reflect.TypeFor[T]())
+	}
+	return client, nil
+}
+
+// QvaPayFactory returns the full client of the backend apiType.
+//
+// Deprecated: use Open with the capability needed, the KindQvaPay backend
+// has no credentials and only implements P2PAPI.
+func QvaPayFactory(apiType string, opts Options) (IQvaPay, error) {
+	return Open[IQvaPay](apiType, opts)
+}
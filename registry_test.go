@@ -0,0 +1,78 @@
+package qvapay_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Open_Capabilities(t *testing.T) {
+	opts := qvapay.Options{BaseURL: "http://localhost", AppID: appID, SecretID: secretID}
+
+	_, err := qvapay.Open[qvapay.AppAPI](qvapay.KindApp, opts)
+	assert.NoError(t, err)
+	_, err = qvapay.Open[qvapay.IQvaPay](qvapay.KindApp, opts)
+	assert.NoError(t, err)
+	_, err = qvapay.Open[qvapay.P2PAPI](qvapay.KindQvaPay, opts)
+	assert.NoError(t, err)
+
+	_, err = qvapay.Open[qvapay.AppAPI](qvapay.KindQvaPay, opts)
+	assert.True(t, errors.Is(err, qvapay.ErrUnsupported))
+	_, err = qvapay.QvaPayFactory(qvapay.KindQvaPay, opts)
+	assert.True(t, errors.Is(err, qvapay.ErrUnsupported))
+	_, ok := qvapay.NewQvaPay(opts).(qvapay.AppAPI)
+	assert.False(t, ok)
+
+	_, err = qvapay.Open[qvapay.P2PAPI]("missing", opts)
+	assert.True(t, errors.Is(err, qvapay.ErrUnknownBackend))
+}
+
+// sandboxBalance is a backend only implementing GetBalance.
+type sandboxBalance struct{}
+
+func (sandboxBalance) GetBalance(context.Context) (qvapay.Amount, error) {
+	return qvapay.MustParseAmount("66"), nil
+}
+
+func Test_Register_Backend(t *testing.T) {
+	qvapay.Register("test-sandbox", func(qvapay.Options) (any, error) { return sandboxBalance{}, nil })
+	assert.Contains(t, qvapay.Backends(), "test-sandbox")
+	assert.Contains(t, qvapay.Backends(), qvapay.KindApp)
+	assert.Panics(t, func() {
+		qvapay.Register("test-sandbox", func(qvapay.Options) (any, error) { return nil, nil })
+	})
+
+	balancer, err := qvapay.Open[interface {
+		GetBalance(context.Context) (qvapay.Amount, error)
+	}]("test-sandbox", qvapay.Options{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	balance, err := balancer.GetBalance(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, qvapay.MustParseAmount("66"), balance)
+
+	_, err = qvapay.Open[qvapay.AppAPI]("test-sandbox", qvapay.Options{})
+	assert.True(t, errors.Is(err, qvapay.ErrUnsupported))
+}
+
+func Test_Open_P2P_Offers(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte(`{"current_page": 1, "data": [{"uuid": "1", "coin": "BTC"}]}`))
+		}),
+	)
+	defer s.Close()
+	p2p, err := qvapay.Open[qvapay.P2PAPI](qvapay.KindQvaPay, qvapay.Options{BaseURL: s.URL})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	offers, err := p2p.Offers(context.Background(), qvapay.QueryParams{})
+	assert.NoError(t, err)
+	assert.Len(t, offers.Data, 1)
+}
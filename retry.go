@@ -0,0 +1,147 @@
+package qvapay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// idempotentRoutes are the routes that are safe to replay, the retry policy
+// is never applied to any other route (e.g. RouteInvoice).
+var idempotentRoutes = map[string]bool{
+	RouteInfo:    true,
+	RouteTxs:     true,
+	RouteTx:      true,
+	RouteBalance: true,
+	RouteOffers:  true,
+}
+
+// RetryPolicy describes how apiCall replays idempotent calls that failed with
+// a transport error or a retryable status code.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values lower than 1 mean a single attempt.
+	MaxAttempts int
+	// BaseDelay is the delay before the second attempt, it doubles on every
+	// following attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts, zero means no cap.
+	MaxDelay time.Duration
+	// Jitter is the fraction (0..1) of every delay that is randomized.
+	Jitter float64
+	// RetryStatus are the status codes that trigger a new attempt.
+	RetryStatus []int
+	// RetryOn decides if a transport error triggers a new attempt,
+	// optional, defaults to any error that is not a context error.
+	RetryOn func(err error) bool
+}
+
+// DefaultRetryPolicy returns a policy with 3 attempts, exponential backoff
+// from 200ms up to 5s with 20% of jitter, retrying 429 and 5xx gateway errors.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Jitter:      0.2,
+		RetryStatus: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+func (p *RetryPolicy) attempts(route string) int {
+	if p == nil || p.MaxAttempts < 1 || !idempotentRoutes[route] {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p *RetryPolicy) shouldRetry(ctx context.Context, status int, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		if p.RetryOn != nil {
+			return p.RetryOn(err)
+		}
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	for _, s := range p.RetryStatus {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns the backoff to wait after the given failed attempt.
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay == 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 && d > 0 {
+		// #nosec G404 -- jitter does not need a secure source
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// RetryError is returned when a call still fails after more than one attempt.
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// CallInfo is filled by the client with details about the last call made
+// with a context returned by WithCallInfo.
+type CallInfo struct {
+	// Attempts is the number of HTTP attempts made for the call.
+	Attempts int
+	// StatusCode is the status code of the last attempt, if any.
+	StatusCode int
+}
+
+type callInfoKey struct{}
+
+// WithCallInfo returns a copy of ctx that makes the client report the call
+// details into info.
+func WithCallInfo(ctx context.Context, info *CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, info)
+}
+
+func callInfoFrom(ctx context.Context) *CallInfo {
+	info, _ := ctx.Value(callInfoKey{}).(*CallInfo)
+	return info
+}
@@ -0,0 +1,101 @@
+package qvapay_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRetryClient(url string) qvapay.PaymentAppClient {
+	return qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL:  url,
+			AppID:    appID,
+			SecretID: secretID,
+			Retry: &qvapay.RetryPolicy{
+				MaxAttempts: 3,
+				BaseDelay:   time.Millisecond,
+				RetryStatus: []int{http.StatusServiceUnavailable},
+			},
+		},
+	)
+}
+
+func Test_Retry_Idempotent_Call(t *testing.T) {
+	var calls int32
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"66.0"}`))
+		}),
+	)
+	defer s.Close()
+
+	info := &qvapay.CallInfo{}
+	ctx := qvapay.WithCallInfo(context.Background(), info)
+	balance, err := newRetryClient(s.URL).GetBalance(ctx)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, qvapay.MustParseAmount("66.0"), balance)
+	assert.Equal(t, 3, info.Attempts)
+	assert.Equal(t, http.StatusOK, info.StatusCode)
+}
+
+func Test_Retry_Skips_Non_Idempotent_Call(t *testing.T) {
+	var calls int32
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}),
+	)
+	defer s.Close()
+
+	info := &qvapay.CallInfo{}
+	ctx := qvapay.WithCallInfo(context.Background(), info)
+	_, err := newRetryClient(s.URL).CreateInvoice(ctx, qvapay.MustParseAmount("25.60"), "Enanitos verdes", "BRID56568989")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, 1, info.Attempts)
+}
+
+func Test_Retry_Stops_On_Context_Cancel(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}),
+	)
+	defer s.Close()
+
+	client := qvapay.NewPaymentAppClient(
+		qvapay.Options{
+			BaseURL:  s.URL,
+			AppID:    appID,
+			SecretID: secretID,
+			Retry: &qvapay.RetryPolicy{
+				MaxAttempts: 5,
+				BaseDelay:   time.Hour,
+				RetryStatus: []int{http.StatusServiceUnavailable},
+			},
+		},
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.GetInfo(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	var retryErr *qvapay.RetryError
+	assert.True(t, errors.As(err, &retryErr))
+	assert.Equal(t, 1, retryErr.Attempts)
+}
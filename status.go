@@ -0,0 +1,127 @@
+package qvapay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TransactionStatus is the status of a transaction, values unknown to the
+// SDK are kept as they come.
+type TransactionStatus string
+
+// Known transaction statuses.
+const (
+	StatusPending    TransactionStatus = "pending"
+	StatusProcessing TransactionStatus = "processing"
+	StatusPaid       TransactionStatus = "paid"
+	StatusCancelled  TransactionStatus = "cancelled"
+	StatusExpired    TransactionStatus = "expired"
+)
+
+// statusAliases maps the spellings seen in the wild to a known status.
+var statusAliases = map[string]TransactionStatus{
+	"canceled":  StatusCancelled,
+	"completed": StatusPaid,
+	"received":  StatusPaid,
+}
+
+// statusRank orders the statuses along the payment lifecycle.
+var statusRank = map[TransactionStatus]int{
+	StatusPending:    0,
+	StatusProcessing: 1,
+	StatusPaid:       2,
+	StatusCancelled:  2,
+	StatusExpired:    2,
+}
+
+// statusTransitions are the allowed changes of status.
+var statusTransitions = map[TransactionStatus][]TransactionStatus{
+	StatusPending:    {StatusProcessing, StatusPaid, StatusCancelled, StatusExpired},
+	StatusProcessing: {StatusPaid, StatusCancelled},
+}
+
+// ParseTransactionStatus normalises s (case, spaces and known aliases), an
+// unknown status is returned as is, lower cased.
+func ParseTransactionStatus(s string) TransactionStatus {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if status, ok := statusAliases[s]; ok {
+		return status
+	}
+	return TransactionStatus(s)
+}
+
+// IsKnown reports whether s is one of the known statuses.
+func (s TransactionStatus) IsKnown() bool {
+	_, ok := statusRank[s]
+	return ok
+}
+
+// IsFinal reports whether s ends the lifecycle of a transaction.
+func (s TransactionStatus) IsFinal() bool {
+	return s == StatusPaid || s == StatusCancelled || s == StatusExpired
+}
+
+// IsPaid reports whether s is StatusPaid.
+func (s TransactionStatus) IsPaid() bool {
+	return s == StatusPaid
+}
+
+func (s TransactionStatus) String() string {
+	return string(s)
+}
+
+// UnmarshalJSON decodes the status with ParseTransactionStatus.
+func (s *TransactionStatus) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return fmt.Errorf("qvapay: invalid transaction status %s", data)
+	}
+	*s = ParseTransactionStatus(str)
+	return nil
+}
+
+// ErrInvalidTransition is matched by every *TransitionError.
+var ErrInvalidTransition = errors.New("qvapay: invalid status transition")
+
+// TransitionError describes a change of status not allowed by the lifecycle.
+type TransitionError struct {
+	From TransactionStatus
+	To   TransactionStatus
+	// Backwards is set when To comes before From in the lifecycle.
+	Backwards bool
+}
+
+func (e *TransitionError) Error() string {
+	kind := "illegal"
+	if e.Backwards {
+		kind = "backwards"
+	}
+	return fmt.Sprintf("qvapay: %s status transition from %q to %q", kind, e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// ValidateTransition returns a *TransitionError if a transaction can't go
+// from one status to the other, staying in the same status is always valid.
+// Transitions from or to unknown statuses are rejected.
+func ValidateTransition(from, to TransactionStatus) error {
+	if from == to {
+		return nil
+	}
+	for _, next := range statusTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	fromRank, fromKnown := statusRank[from]
+	toRank, toKnown := statusRank[to]
+	return &TransitionError{
+		From:      from,
+		To:        to,
+		Backwards: fromKnown && toKnown && toRank < fromRank,
+	}
+}
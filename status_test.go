@@ -0,0 +1,42 @@
+package qvapay_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Parse_Transaction_Status(t *testing.T) {
+	assert.Equal(t, qvapay.StatusPaid, qvapay.ParseTransactionStatus(" Paid "))
+	assert.Equal(t, qvapay.StatusCancelled, qvapay.ParseTransactionStatus("canceled"))
+
+	unknown := qvapay.ParseTransactionStatus("On-Hold")
+	assert.Equal(t, qvapay.TransactionStatus("on-hold"), unknown)
+	assert.False(t, unknown.IsKnown())
+	assert.False(t, unknown.IsFinal())
+
+	tx := qvapay.Transaction{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"status":"PAID"}`), &tx))
+	assert.True(t, tx.Status.IsPaid())
+	assert.True(t, tx.Status.IsFinal())
+}
+
+func Test_Validate_Transition(t *testing.T) {
+	assert.NoError(t, qvapay.ValidateTransition(qvapay.StatusPending, qvapay.StatusPaid))
+	assert.NoError(t, qvapay.ValidateTransition(qvapay.StatusPaid, qvapay.StatusPaid))
+
+	err := qvapay.ValidateTransition(qvapay.StatusPaid, qvapay.StatusPending)
+	assert.True(t, errors.Is(err, qvapay.ErrInvalidTransition))
+	var transitionErr *qvapay.TransitionError
+	if assert.True(t, errors.As(err, &transitionErr)) {
+		assert.True(t, transitionErr.Backwards)
+	}
+
+	err = qvapay.ValidateTransition(qvapay.StatusCancelled, qvapay.StatusPaid)
+	if assert.True(t, errors.As(err, &transitionErr)) {
+		assert.False(t, transitionErr.Backwards)
+	}
+}
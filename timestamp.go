@@ -0,0 +1,97 @@
+package qvapay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// TimestampLayout is the layout used to encode a Timestamp, the same one the
+// API uses.
+const TimestampLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+// zonedLayouts are parsed with their own zone, localLayouts in the
+// timestamp location.
+var (
+	zonedLayouts = []string{time.RFC3339Nano}
+	localLayouts = []string{
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02",
+	}
+)
+
+var timestampLocation atomic.Pointer[time.Location]
+
+// SetTimestampLocation sets the time zone of the timestamps the API sends
+// without zone, such as "2021-01-10 04:35:33", it defaults to UTC.
+func SetTimestampLocation(loc *time.Location) {
+	timestampLocation.Store(loc)
+}
+
+// TimestampLocation returns the time zone set with SetTimestampLocation.
+func TimestampLocation() *time.Location {
+	if loc := timestampLocation.Load(); loc != nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// Timestamp is a time returned by the API, always normalised to UTC.
+type Timestamp struct {
+	time.Time
+}
+
+// ParseTimestamp parses the ISO8601 layouts, with and without zone, and the
+// "2006-01-02 15:04:05" layout used by the API.
+func ParseTimestamp(s string) (Timestamp, error) {
+	for _, layout := range zonedLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return Timestamp{t.UTC()}, nil
+		}
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, s, TimestampLocation()); err == nil {
+			return Timestamp{t.UTC()}, nil
+		}
+	}
+	return Timestamp{}, fmt.Errorf("qvapay: invalid timestamp %q", s)
+}
+
+// String formats the timestamp with TimestampLayout.
+func (t Timestamp) String() string {
+	return t.UTC().Format(TimestampLayout)
+}
+
+// MarshalJSON encodes the timestamp as a TimestampLayout string, or null.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON decodes a string in any of the layouts of ParseTimestamp,
+// null or an empty string.
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*t = Timestamp{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("qvapay: invalid timestamp %s", data)
+	}
+	if s == "" {
+		*t = Timestamp{}
+		return nil
+	}
+	parsed, err := ParseTimestamp(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
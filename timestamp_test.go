@@ -0,0 +1,57 @@
+package qvapay_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Parse_Timestamp_Layouts(t *testing.T) {
+	expected := time.Date(2021, 1, 10, 4, 35, 33, 0, time.UTC)
+	for _, in := range []string{
+		"2021-01-10T04:35:33.000000Z",
+		"2021-01-10T04:35:33Z",
+		"2021-01-10T00:35:33-04:00",
+		"2021-01-10T04:35:33",
+		"2021-01-10 04:35:33",
+	} {
+		ts, err := qvapay.ParseTimestamp(in)
+		if assert.NoError(t, err, in) {
+			assert.True(t, expected.Equal(ts.Time), in)
+			assert.Equal(t, time.UTC, ts.Location(), in)
+		}
+	}
+	_, err := qvapay.ParseTimestamp("10/01/2021")
+	assert.Error(t, err)
+}
+
+func Test_Timestamp_Location(t *testing.T) {
+	havana, err := time.LoadLocation("America/Havana")
+	if err != nil {
+		t.Skip(err.Error())
+	}
+	qvapay.SetTimestampLocation(havana)
+	defer qvapay.SetTimestampLocation(time.UTC)
+
+	ts, err := qvapay.ParseTimestamp("2021-01-10 04:35:33")
+	assert.NoError(t, err)
+	assert.Equal(t, "2021-01-10T09:35:33.000000Z", ts.String())
+}
+
+func Test_Transaction_Timestamps_Round_Trip(t *testing.T) {
+	tx := &qvapay.TransactionReponse{}
+	err := json.Unmarshal([]byte(`{"uuid":"1","created_at":"2021-02-06 18:10:09","updated_at":null}`), tx)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, 2021, tx.CreatedAt.Year())
+	assert.True(t, tx.UpdatedAt.IsZero())
+
+	again := &qvapay.TransactionReponse{}
+	assert.NoError(t, json.Unmarshal([]byte(tx.ToJSON()), again))
+	assert.True(t, tx.CreatedAt.Equal(again.CreatedAt.Time))
+	assert.Contains(t, tx.ToJSON(), `"created_at":"2021-02-06T18:10:09.000000Z"`)
+}
@@ -0,0 +1,118 @@
+package qvapay
+
+import (
+	"context"
+	"net/http"
+)
+
+// Operation names, one for every IQvaPay method.
+const (
+	OpGetInfo         = "GetInfo"
+	OpCreateInvoice   = "CreateInvoice"
+	OpGetTransactions = "GetTransactions"
+	OpGetTransaction  = "GetTransaction"
+	OpGetBalance      = "GetBalance"
+	OpOffers          = "Offers"
+)
+
+// Attribute keys set on the spans.
+const (
+	AttrRoute           = "qvapay.route"
+	AttrStatusCode      = "http.status_code"
+	AttrAttempts        = "qvapay.attempts"
+	AttrRemoteID        = "qvapay.remote_id"
+	AttrTransactionUUID = "qvapay.transaction_uuid"
+	AttrPage            = "qvapay.page"
+)
+
+// Attribute is a key/value pair set on a span, Value is a string, an int, a
+// float64 or a bool.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Tracer starts a span for every IQvaPay method call, see the qvapayotel
+// package for an OpenTelemetry implementation.
+type Tracer interface {
+	// Start starts a span named after the operation, as a child of the span
+	// in ctx if any.
+	Start(ctx context.Context, operation string, attrs ...Attribute) (context.Context, Span)
+	// Inject writes the propagation headers of the span in ctx into header.
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+type spanKey struct{}
+
+// startSpan starts the span of an IQvaPay method, it's a no-op without
+// tracer.
+func (c *client) startSpan(ctx context.Context, operation string, route string, attrs ...Attribute) (context.Context, *span) {
+	if c.tracer == nil {
+		return ctx, nil
+	}
+	attrs = append([]Attribute{{Key: AttrRoute, Value: route}}, attrs...)
+	ctx, s := c.tracer.Start(ctx, operation, attrs...)
+	sp := &span{Span: s}
+	return context.WithValue(ctx, spanKey{}, sp), sp
+}
+
+// span wraps the Span of a call so apiCall can find it and record the
+// HTTP details.
+type span struct {
+	Span
+}
+
+func spanFromContext(ctx context.Context) *span {
+	s, _ := ctx.Value(spanKey{}).(*span)
+	return s
+}
+
+func (s *span) setAttributes(attrs ...Attribute) {
+	if s != nil {
+		s.SetAttributes(attrs...)
+	}
+}
+
+// finish records err, if any, and ends the span.
+func (s *span) finish(err error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		s.RecordError(err)
+	}
+	s.End()
+}
+
+// paramsAttributes returns the span attributes known before the call.
+func paramsAttributes(call *Call) []Attribute {
+	switch p := call.Params.(type) {
+	case *CreateInvoiceParams:
+		return []Attribute{{Key: AttrRemoteID, Value: p.RemoteID}}
+	case *GetTransactionParams:
+		return []Attribute{{Key: AttrTransactionUUID, Value: p.ID}}
+	case *APIQueryParams:
+		return []Attribute{{Key: AttrPage, Value: p.Page}}
+	case *QueryParams:
+		return []Attribute{{Key: AttrPage, Value: p.Page}}
+	}
+	return nil
+}
+
+// resultAttributes returns the span attributes known from the response.
+func resultAttributes(call *Call) []Attribute {
+	switch r := call.Result.(type) {
+	case *InvoiceResponse:
+		return []Attribute{{Key: AttrTransactionUUID, Value: r.TransactionUUID}}
+	case *TransactionReponse:
+		return []Attribute{{Key: AttrRemoteID, Value: r.RemoteID}}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package qvapay
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"time"
+)
+
+// TLSOptions configures the TLS connections of the transport built by the
+// client.
+type TLSOptions struct {
+	// RootCAs are the trusted root certificates, nil uses the system pool.
+	RootCAs *x509.CertPool
+	// Certificates are the client certificates presented to the server.
+	Certificates []tls.Certificate
+	// MinVersion is the minimum TLS version, defaults to TLS 1.2.
+	MinVersion uint16
+	// InsecureSkipVerify disables the server certificate verification.
+	InsecureSkipVerify bool
+}
+
+func (o *TLSOptions) config(skipVerify bool) *tls.Config {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if o != nil {
+		cfg.RootCAs = o.RootCAs
+		cfg.Certificates = o.Certificates
+		if o.MinVersion != 0 {
+			cfg.MinVersion = o.MinVersion
+		}
+		skipVerify = skipVerify || o.InsecureSkipVerify
+	}
+	// #nosec G402 -- only when asked for through SkipVerify
+	cfg.InsecureSkipVerify = skipVerify
+	return cfg
+}
+
+// newHTTPClient returns the HTTP client of a new client. A caller supplied
+// HttpClient or Transport is used as is, otherwise the client owns a new
+// transport built from the TLS options.
+func newHTTPClient(opts Options) *http.Client {
+	if opts.HttpClient != nil {
+		return opts.HttpClient
+	}
+	if opts.Transport != nil {
+		return &http.Client{Transport: opts.Transport}
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			MaxIdleConns:          256,
+			MaxIdleConnsPerHost:   256,
+			IdleConnTimeout:       60 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+			DisableCompression:    true,
+			TLSClientConfig:       opts.TLS.config(opts.SkipVerify),
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package qvapay_test
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingTransport struct {
+	calls int
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.calls++
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func Test_Clients_Do_Not_Touch_Shared_Transports(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte(`{"66.0"}`))
+		}),
+	)
+	defer s.Close()
+
+	defaultTransport := http.DefaultClient.Transport
+	qvapay.NewPaymentAppClient(qvapay.Options{BaseURL: s.URL, SkipVerify: true})
+	qvapay.NewQvaPay(qvapay.Options{BaseURL: s.URL})
+	assert.Equal(t, defaultTransport, http.DefaultClient.Transport)
+
+	transport := &countingTransport{}
+	httpClient := &http.Client{Transport: transport}
+	client := qvapay.NewPaymentAppClient(qvapay.Options{BaseURL: s.URL, HttpClient: httpClient, SkipVerify: true})
+	_, err := client.GetBalance(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, transport, httpClient.Transport)
+	assert.Equal(t, 1, transport.calls)
+}
+
+func Test_TLS_Root_CAs(t *testing.T) {
+	s := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte(`{"66.0"}`))
+		}),
+	)
+	defer s.Close()
+
+	_, err := qvapay.NewPaymentAppClient(qvapay.Options{BaseURL: s.URL}).GetBalance(context.Background())
+	assert.Error(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(s.Certificate())
+	client := qvapay.NewPaymentAppClient(qvapay.Options{
+		BaseURL: s.URL,
+		TLS:     &qvapay.TLSOptions{RootCAs: pool},
+	})
+	balance, err := client.GetBalance(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, qvapay.MustParseAmount("66.0"), balance)
+}
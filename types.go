@@ -2,6 +2,7 @@ package qvapay
 
 import (
 	"io"
+	"log/slog"
 	"net/http"
 )
 
@@ -9,13 +10,43 @@ type (
 	Options struct {
 		//  API's base url
 		BaseURL string
-		//optional, defaults to http.DefaultClient
+		// optional, used as is, defaults to a client that owns its own
+		// transport
 		HttpClient *http.Client
+		// optional, used as is when HttpClient is nil
+		Transport http.RoundTripper
+		// optional, TLS settings of the client own transport, ignored
+		// when HttpClient or Transport is set
+		TLS *TLSOptions
 		//optional for debuging
 		Debug io.Writer
 		// App endpoints
-		AppID      string
-		SecretID   string
+		AppID    string
+		SecretID string
+		// optional, skips the TLS verification of the client own transport
 		SkipVerify bool
+		// optional, retry policy for idempotent calls, nil disables retries
+		Retry *RetryPolicy
+		// optional, client side rate limiter, it can be shared by clients
+		// that use the same credentials
+		RateLimiter *RateLimiter
+		// optional, extra keys masked in debug output and errors, on top
+		// of DefaultRedactKeys
+		RedactKeys []string
+		// optional, where the app credentials are sent, defaults to the
+		// query string
+		Credentials CredentialsMode
+		// optional, receives the errors that don't make a call fail, such
+		// as a failing debug writer, defaults to the debug output
+		ErrorHandler func(error)
+		// optional, structured logger that receives one record per call,
+		// bodies are logged when the debug level is enabled
+		Logger *slog.Logger
+		// optional, starts a span for every call
+		Tracer Tracer
+		// optional, collects request counts, latencies, errors and retries
+		Metrics Metrics
+		// optional, wraps every call, the first middleware is the outermost
+		Middlewares []Middleware
 	}
 )
@@ -0,0 +1,403 @@
+package qvapay
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrWatchExpired ends a watch that reached WatcherOptions.Expiry.
+	ErrWatchExpired = errors.New("qvapay: watch expired")
+	// ErrWatcherClosed ends the watches of a closed Watcher.
+	ErrWatcherClosed = errors.New("qvapay: watcher closed")
+	// ErrNotPaid is returned by WaitForPayment when the transaction ends
+	// without being paid.
+	ErrNotPaid = errors.New("qvapay: transaction not paid")
+)
+
+// TransactionGetter is the part of IQvaPay needed to watch a transaction.
+type TransactionGetter interface {
+	GetTransaction(ctx context.Context, id string) (*TransactionReponse, error)
+}
+
+// WatcherOptions configures a Watcher.
+type WatcherOptions struct {
+	// MinInterval is the delay before the first poll of a transaction and
+	// after a change of status, 2s by default.
+	MinInterval time.Duration
+	// MaxInterval caps the delay growing while the status doesn't change,
+	// 1m by default.
+	MaxInterval time.Duration
+	// Multiplier grows the delay after each poll without change, 1.5 by
+	// default.
+	Multiplier float64
+	// Workers is the number of polls running at once, shared by every
+	// watch, 4 by default.
+	Workers int
+	// Expiry ends a watch with ErrWatchExpired, 0 never expires.
+	Expiry time.Duration
+	// optional, receives the polling errors that don't end a watch
+	ErrorHandler func(error)
+}
+
+func (o WatcherOptions) withDefaults() WatcherOptions {
+	if o.MinInterval <= 0 {
+		o.MinInterval = 2 * time.Second
+	}
+	if o.MaxInterval < o.MinInterval {
+		o.MaxInterval = max(time.Minute, o.MinInterval)
+	}
+	if o.Multiplier < 1 {
+		o.Multiplier = 1.5
+	}
+	if o.Workers <= 0 {
+		o.Workers = 4
+	}
+	return o
+}
+
+// StatusChange is a change of status seen by a watch, From is empty on the
+// first poll.
+type StatusChange struct {
+	TransactionUUID string
+	From            TransactionStatus
+	To              TransactionStatus
+	Transaction     *TransactionReponse
+}
+
+// Watcher polls transactions until they reach a final status. Every watch
+// is scheduled by a single goroutine and polled by a fixed pool of workers,
+// so thousands of pending invoices don't cost thousands of loops.
+//
+//	w := qvapay.NewWatcher(client, qvapay.WatcherOptions{Expiry: time.Hour})
+//	defer w.Close()
+//	tx, err := w.WaitForPayment(ctx, invoice.TransactionUUID)
+type Watcher struct {
+	api    TransactionGetter
+	opts   WatcherOptions
+	ctx    context.Context
+	cancel context.CancelFunc
+	jobs   chan *Watch
+	wake   chan struct{}
+	wg     sync.WaitGroup
+
+	mu    sync.Mutex
+	queue watchQueue
+}
+
+// NewWatcher starts a Watcher, Close stops it.
+func NewWatcher(api TransactionGetter, opts WatcherOptions) *Watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Watcher{
+		api:    api,
+		opts:   opts.withDefaults(),
+		ctx:    ctx,
+		cancel: cancel,
+		jobs:   make(chan *Watch),
+		wake:   make(chan struct{}, 1),
+	}
+	w.wg.Add(1 + w.opts.Workers)
+	go w.schedule()
+	for i := 0; i < w.opts.Workers; i++ {
+		go w.work()
+	}
+	return w
+}
+
+// Watch polls the transaction id until it reaches a final status, ctx is
+// done or the watch expires, or the API answers ErrNotFound or
+// ErrUnauthorized. onChange, if not nil, is called on every change of status,
+// from a worker goroutine, it must not block nor call Wait.
+func (w *Watcher) Watch(ctx context.Context, id string, onChange func(StatusChange)) *Watch {
+	ctx, cancel := context.WithCancelCause(ctx)
+	watch := &Watch{
+		id:       id,
+		onChange: onChange,
+		cancel:   cancel,
+		interval: w.opts.MinInterval,
+		index:    -1,
+		done:     make(chan struct{}),
+	}
+	watch.ctx = ctx
+	if w.opts.Expiry > 0 {
+		watch.ctx, watch.stopExpiry = context.WithTimeoutCause(ctx, w.opts.Expiry, ErrWatchExpired)
+	}
+	stopClose := context.AfterFunc(w.ctx, func() { cancel(ErrWatcherClosed) })
+	context.AfterFunc(watch.ctx, func() {
+		stopClose()
+		w.finish(watch, nil, context.Cause(watch.ctx))
+	})
+	w.enqueue(watch, w.opts.MinInterval)
+	return watch
+}
+
+// WatchInvoice watches the transaction of an invoice created by
+// CreateInvoice.
+func (w *Watcher) WatchInvoice(ctx context.Context, invoice *InvoiceResponse, onChange func(StatusChange)) *Watch {
+	return w.Watch(ctx, invoice.TransactionUUID, onChange)
+}
+
+// WaitForPayment blocks until the transaction id reaches a final status, it
+// fails with ErrNotPaid when the transaction is cancelled or expired.
+func (w *Watcher) WaitForPayment(ctx context.Context, id string) (*TransactionReponse, error) {
+	tx, err := w.Watch(ctx, id, nil).Wait()
+	if err != nil {
+		return nil, err
+	}
+	if !tx.Status.IsPaid() {
+		return tx, fmt.Errorf("%w: transaction %s is %s", ErrNotPaid, id, tx.Status)
+	}
+	return tx, nil
+}
+
+// Close ends every watch with ErrWatcherClosed and stops the watcher.
+func (w *Watcher) Close() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// schedule hands the watches to the workers when their poll is due.
+func (w *Watcher) schedule() {
+	defer w.wg.Done()
+	defer close(w.jobs)
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		due, wait := w.due()
+		if due != nil {
+			select {
+			case w.jobs <- due:
+				continue
+			case <-w.ctx.Done():
+				return
+			}
+		}
+		var tick <-chan time.Time
+		if wait > 0 {
+			timer.Reset(wait)
+			tick = timer.C
+		}
+		select {
+		case <-w.wake:
+		case <-tick:
+		case <-w.ctx.Done():
+			return
+		}
+		timer.Stop()
+	}
+}
+
+// due pops the next watch to poll, or returns how long to wait for it, 0
+// when the queue is empty.
+func (w *Watcher) due() (*Watch, time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.queue) == 0 {
+		return nil, 0
+	}
+	if wait := time.Until(w.queue[0].next); wait > 0 {
+		return nil, wait
+	}
+	return heap.Pop(&w.queue).(*Watch), 0
+}
+
+func (w *Watcher) work() {
+	defer w.wg.Done()
+	for watch := range w.jobs {
+		w.poll(watch)
+	}
+}
+
+// poll gets the transaction once, reports the change of status and either
+// finishes the watch or schedules the next poll.
+func (w *Watcher) poll(watch *Watch) {
+	if watch.ctx.Err() != nil {
+		return
+	}
+	tx, err := w.api.GetTransaction(watch.ctx, watch.id)
+	if err != nil {
+		if watch.ctx.Err() != nil {
+			return
+		}
+		// polling again won't fix bad credentials or a transaction that
+		// doesn't exist
+		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrNotFound) {
+			w.finish(watch, nil, err)
+			return
+		}
+		if w.opts.ErrorHandler != nil {
+			w.opts.ErrorHandler(fmt.Errorf("qvapay: watching transaction %s: %w", watch.id, err))
+		}
+		w.enqueue(watch, watch.backoff(w.opts))
+		return
+	}
+
+	watch.mu.Lock()
+	if watch.finished {
+		watch.mu.Unlock()
+		return
+	}
+	from := watch.status
+	changed := tx.Status != from
+	watch.status = tx.Status
+	watch.mu.Unlock()
+
+	// onChange runs unlocked so it can call the methods of the watch, and
+	// before the watch completes so Wait returns after the last change
+	if changed && watch.onChange != nil {
+		watch.onChange(StatusChange{TransactionUUID: watch.id, From: from, To: tx.Status, Transaction: tx})
+	}
+	watch.mu.Lock()
+	final := tx.Status.IsFinal() && watch.complete(tx, nil)
+	watch.mu.Unlock()
+
+	switch {
+	case final:
+		w.release(watch)
+	case changed:
+		watch.interval = w.opts.MinInterval
+		w.enqueue(watch, watch.interval)
+	default:
+		w.enqueue(watch, watch.backoff(w.opts))
+	}
+}
+
+// enqueue schedules the next poll of watch in d.
+func (w *Watcher) enqueue(watch *Watch, d time.Duration) {
+	if watch.ctx.Err() != nil {
+		return
+	}
+	w.mu.Lock()
+	watch.next = time.Now().Add(d)
+	if watch.index < 0 {
+		heap.Push(&w.queue, watch)
+	} else {
+		heap.Fix(&w.queue, watch.index)
+	}
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Watcher) finish(watch *Watch, tx *TransactionReponse, err error) {
+	watch.mu.Lock()
+	ok := watch.complete(tx, err)
+	watch.mu.Unlock()
+	if ok {
+		w.release(watch)
+	}
+}
+
+// release removes a finished watch from the queue and frees its context.
+func (w *Watcher) release(watch *Watch) {
+	watch.cancel(context.Canceled)
+	if watch.stopExpiry != nil {
+		watch.stopExpiry()
+	}
+	w.mu.Lock()
+	if watch.index >= 0 {
+		heap.Remove(&w.queue, watch.index)
+	}
+	w.mu.Unlock()
+}
+
+// Watch is a transaction watched by a Watcher.
+type Watch struct {
+	id         string
+	onChange   func(StatusChange)
+	ctx        context.Context
+	cancel     context.CancelCauseFunc
+	stopExpiry context.CancelFunc
+	interval   time.Duration
+
+	// guarded by the watcher mutex
+	next  time.Time
+	index int
+
+	mu       sync.Mutex
+	status   TransactionStatus
+	finished bool
+	result   *TransactionReponse
+	err      error
+	done     chan struct{}
+}
+
+// complete sets the result of the watch, it must be called with mu held and
+// reports whether the watch wasn't already finished.
+func (watch *Watch) complete(tx *TransactionReponse, err error) bool {
+	if watch.finished {
+		return false
+	}
+	watch.finished = true
+	watch.result, watch.err = tx, err
+	close(watch.done)
+	return true
+}
+
+func (watch *Watch) backoff(opts WatcherOptions) time.Duration {
+	watch.interval = min(time.Duration(float64(watch.interval)*opts.Multiplier), opts.MaxInterval)
+	return watch.interval
+}
+
+// ID returns the uuid of the watched transaction.
+func (watch *Watch) ID() string {
+	return watch.id
+}
+
+// Status returns the last status seen, empty before the first poll.
+func (watch *Watch) Status() TransactionStatus {
+	watch.mu.Lock()
+	defer watch.mu.Unlock()
+	return watch.status
+}
+
+// Done is closed when the watch ends.
+func (watch *Watch) Done() <-chan struct{} {
+	return watch.done
+}
+
+// Wait blocks until the watch ends and returns the transaction in its final
+// status, or why the watch ended before: the ctx error, ErrWatchExpired,
+// ErrWatcherClosed or an authentication error.
+func (watch *Watch) Wait() (*TransactionReponse, error) {
+	<-watch.done
+	return watch.result, watch.err
+}
+
+// Cancel ends the watch with context.Canceled.
+func (watch *Watch) Cancel() {
+	watch.cancel(context.Canceled)
+}
+
+// watchQueue is a heap of watches ordered by their next poll.
+type watchQueue []*Watch
+
+func (q watchQueue) Len() int           { return len(q) }
+func (q watchQueue) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+
+func (q watchQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *watchQueue) Push(x any) {
+	watch := x.(*Watch)
+	watch.index = len(*q)
+	*q = append(*q, watch)
+}
+
+func (q *watchQueue) Pop() any {
+	old := *q
+	watch := old[len(old)-1]
+	old[len(old)-1] = nil
+	watch.index = -1
+	*q = old[:len(old)-1]
+	return watch
+}
@@ -0,0 +1,196 @@
+package qvapay_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// scriptedTransactions answers the statuses of each transaction in turn,
+// repeating the last one.
+type scriptedTransactions struct {
+	mu       sync.Mutex
+	statuses map[string][]qvapay.TransactionStatus
+	polls    map[string]int
+	inFlight int32
+	maxProcs int32
+}
+
+func (s *scriptedTransactions) GetTransaction(_ context.Context, id string) (*qvapay.TransactionReponse, error) {
+	n := atomic.AddInt32(&s.inFlight, 1)
+	defer atomic.AddInt32(&s.inFlight, -1)
+	for {
+		m := atomic.LoadInt32(&s.maxProcs)
+		if n <= m || atomic.CompareAndSwapInt32(&s.maxProcs, m, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses, ok := s.statuses[id]
+	if !ok {
+		return nil, &qvapay.StatusError{StatusCode: http.StatusNotFound}
+	}
+	poll := min(s.polls[id], len(statuses)-1)
+	s.polls[id]++
+	return &qvapay.TransactionReponse{ID: id, Status: statuses[poll]}, nil
+}
+
+func newScript(statuses map[string][]qvapay.TransactionStatus) *scriptedTransactions {
+	return &scriptedTransactions{statuses: statuses, polls: map[string]int{}}
+}
+
+func fastWatcher(api qvapay.TransactionGetter, expiry time.Duration) *qvapay.Watcher {
+	return qvapay.NewWatcher(api, qvapay.WatcherOptions{
+		MinInterval: time.Millisecond,
+		MaxInterval: 5 * time.Millisecond,
+		Workers:     2,
+		Expiry:      expiry,
+	})
+}
+
+func Test_Watch_Reports_Changes_Until_Final(t *testing.T) {
+	api := newScript(map[string][]qvapay.TransactionStatus{
+		"tx": {qvapay.StatusPending, qvapay.StatusPending, qvapay.StatusProcessing, qvapay.StatusPaid},
+	})
+	w := fastWatcher(api, 0)
+	defer w.Close()
+
+	var changes []qvapay.StatusChange
+	watch := w.WatchInvoice(context.Background(), &qvapay.InvoiceResponse{TransactionUUID: "tx"}, func(c qvapay.StatusChange) {
+		changes = append(changes, c)
+	})
+	tx, err := watch.Wait()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, qvapay.StatusPaid, tx.Status)
+	assert.Equal(t, qvapay.StatusPaid, watch.Status())
+	if assert.Len(t, changes, 3) {
+		assert.Equal(t, qvapay.TransactionStatus(""), changes[0].From)
+		assert.Equal(t, qvapay.StatusPending, changes[0].To)
+		assert.Equal(t, qvapay.StatusProcessing, changes[1].To)
+		assert.Equal(t, qvapay.StatusProcessing, changes[2].From)
+		assert.Equal(t, qvapay.StatusPaid, changes[2].To)
+	}
+}
+
+func Test_Wait_For_Payment_Not_Paid(t *testing.T) {
+	api := newScript(map[string][]qvapay.TransactionStatus{
+		"tx": {qvapay.StatusPending, qvapay.StatusCancelled},
+	})
+	w := fastWatcher(api, 0)
+	defer w.Close()
+
+	tx, err := w.WaitForPayment(context.Background(), "tx")
+	assert.True(t, errors.Is(err, qvapay.ErrNotPaid))
+	assert.Equal(t, qvapay.StatusCancelled, tx.Status)
+}
+
+func Test_Watch_Ends_On_Expiry_Cancel_And_Close(t *testing.T) {
+	api := newScript(map[string][]qvapay.TransactionStatus{
+		"tx": {qvapay.StatusPending},
+	})
+	w := fastWatcher(api, 20*time.Millisecond)
+
+	_, err := w.WaitForPayment(context.Background(), "tx")
+	assert.True(t, errors.Is(err, qvapay.ErrWatchExpired))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watch := w.Watch(ctx, "tx", nil)
+	cancel()
+	_, err = watch.Wait()
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	watch = w.Watch(context.Background(), "tx", nil)
+	w.Close()
+	_, err = watch.Wait()
+	assert.True(t, errors.Is(err, qvapay.ErrWatcherClosed))
+}
+
+func Test_Watch_Ends_On_Not_Found(t *testing.T) {
+	w := fastWatcher(newScript(nil), 0)
+	defer w.Close()
+
+	_, err := w.Watch(context.Background(), "missing", nil).Wait()
+	assert.True(t, errors.Is(err, qvapay.ErrNotFound))
+}
+
+func Test_Watch_Callback_Can_Use_The_Watch(t *testing.T) {
+	api := newScript(map[string][]qvapay.TransactionStatus{
+		"tx": {qvapay.StatusPending, qvapay.StatusPaid},
+	})
+	w := fastWatcher(api, 0)
+	defer w.Close()
+
+	var watch *qvapay.Watch
+	var seen []qvapay.TransactionStatus
+	ready := make(chan struct{})
+	watch = w.Watch(context.Background(), "tx", func(c qvapay.StatusChange) {
+		<-ready
+		seen = append(seen, watch.Status())
+	})
+	close(ready)
+	_, err := watch.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, []qvapay.TransactionStatus{qvapay.StatusPending, qvapay.StatusPaid}, seen)
+}
+
+func Test_Watch_Shares_The_Workers(t *testing.T) {
+	statuses := map[string][]qvapay.TransactionStatus{}
+	for i := 0; i < 200; i++ {
+		statuses[fmt.Sprint(i)] = []qvapay.TransactionStatus{qvapay.StatusPending, qvapay.StatusPaid}
+	}
+	api := newScript(statuses)
+	w := fastWatcher(api, 0)
+	defer w.Close()
+
+	watches := make([]*qvapay.Watch, 0, len(statuses))
+	for id := range statuses {
+		watches = append(watches, w.Watch(context.Background(), id, nil))
+	}
+	for _, watch := range watches {
+		tx, err := watch.Wait()
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		assert.Equal(t, qvapay.StatusPaid, tx.Status)
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&api.maxProcs), int32(2))
+}
+
+func Test_Watch_Client(t *testing.T) {
+	var polls int32
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			status := "pending"
+			if atomic.AddInt32(&polls, 1) > 2 {
+				status = "paid"
+			}
+			fmt.Fprintf(w, `{"uuid":"6507ee0d-db6c-4aa9-b59a-75dc7f6eab52","status":%q}`, status)
+		}),
+	)
+	defer s.Close()
+
+	client := qvapay.NewPaymentAppClient(qvapay.Options{BaseURL: s.URL, AppID: appID, SecretID: secretID})
+	w := fastWatcher(client, time.Second)
+	defer w.Close()
+
+	tx, err := w.WaitForPayment(context.Background(), "6507ee0d-db6c-4aa9-b59a-75dc7f6eab52")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.True(t, tx.Status.IsPaid())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&polls))
+}
@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/kenriortega/qvapay-go"
+)
+
+// ErrInvalidPayload is returned for callbacks that can't be parsed into a
+// PaymentEvent.
+var ErrInvalidPayload = errors.New("webhook: invalid payload")
+
+// PaymentEvent is a payment notification sent by QvaPay to the app callback.
+type PaymentEvent struct {
+	TransactionUUID string                   `json:"transaction_uuid"`
+	RemoteID        string                   `json:"remote_id,omitempty"`
+	Amount          qvapay.Amount            `json:"amount"`
+	Status          qvapay.TransactionStatus `json:"status"`
+	// Confirmed is set when the event was checked against GetTransaction.
+	Confirmed bool `json:"confirmed,omitempty"`
+}
+
+// field names accepted for every PaymentEvent field, in order of preference.
+var (
+	uuidKeys     = []string{"transaction_uuid", "transation_uuid", "uuid", "id"}
+	remoteIDKeys = []string{"remote_id"}
+	amountKeys   = []string{"amount"}
+	statusKeys   = []string{"status"}
+)
+
+// ParseRequest reads a callback request, either a JSON body, a form body or
+// query params. The status of a callback without one is left empty, nothing
+// in a callback is trusted until it's confirmed.
+func ParseRequest(r *http.Request, maxBodySize int64) (PaymentEvent, error) {
+	values := url.Values{}
+	for k, vs := range r.URL.Query() {
+		values[k] = vs
+	}
+	if r.Body != nil {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+		if err != nil {
+			return PaymentEvent{}, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+		if int64(len(body)) > maxBodySize {
+			return PaymentEvent{}, fmt.Errorf("%w: body larger than %d bytes", ErrInvalidPayload, maxBodySize)
+		}
+		if err := parseBody(r.Header.Get("Content-Type"), body, values); err != nil {
+			return PaymentEvent{}, err
+		}
+	}
+	return parseValues(values)
+}
+
+func parseBody(contentType string, body []byte, values url.Values) error {
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+		for k, vs := range form {
+			values[k] = vs
+		}
+		return nil
+	}
+	fields := map[string]any{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	for k, v := range fields {
+		switch v := v.(type) {
+		case string:
+			values.Set(k, v)
+		case bool:
+			values.Set(k, fmt.Sprint(v))
+		case json.Number:
+			values.Set(k, v.String())
+		}
+	}
+	return nil
+}
+
+func parseValues(values url.Values) (PaymentEvent, error) {
+	event := PaymentEvent{
+		TransactionUUID: first(values, uuidKeys),
+		RemoteID:        first(values, remoteIDKeys),
+		Status:          qvapay.ParseTransactionStatus(first(values, statusKeys)),
+	}
+	if event.TransactionUUID == "" {
+		return PaymentEvent{}, fmt.Errorf("%w: missing transaction uuid", ErrInvalidPayload)
+	}
+	if amount := first(values, amountKeys); amount != "" {
+		a, err := qvapay.ParseAmount(amount)
+		if err != nil {
+			return PaymentEvent{}, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+		if a.Sign() < 0 {
+			return PaymentEvent{}, fmt.Errorf("%w: negative amount %s", ErrInvalidPayload, a)
+		}
+		event.Amount = a
+	}
+	return event, nil
+}
+
+func first(values url.Values, keys []string) string {
+	for _, k := range keys {
+		if v := strings.TrimSpace(values.Get(k)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
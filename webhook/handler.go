@@ -0,0 +1,254 @@
+// Package webhook receives the payment notifications QvaPay sends to the
+// app callback URL (App.Callback).
+//
+//	h := webhook.NewHandler(webhook.Options{Confirmer: client})
+//	h.Handle(webhook.EventHandlerFunc(func(ctx context.Context, e webhook.PaymentEvent) error {
+//		return orders.MarkPaid(ctx, e.RemoteID, e.Amount)
+//	}))
+//	http.Handle("/webhook", h)
+//
+// Anyone can call the callback URL, the callbacks aren't signed. Without a
+// Confirmer the events are exactly what the caller sent and prove nothing,
+// set Options.Confirmer to trust the status, amount and remote_id of the
+// events, which then come from the API.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/kenriortega/qvapay-go"
+)
+
+// DefaultMaxBodySize is the largest callback body accepted by default.
+const DefaultMaxBodySize = 64 << 10
+
+var (
+	// ErrMismatch is returned when a callback doesn't match the transaction
+	// returned by the API.
+	ErrMismatch = errors.New("webhook: event doesn't match the transaction")
+	// ErrNotSettled is returned when the API doesn't report a final status
+	// for the transaction of a callback yet, the handler answers 503 so
+	// QvaPay delivers the callback again.
+	ErrNotSettled = errors.New("webhook: transaction not settled")
+)
+
+// Confirmer fetches a transaction to confirm a callback, a qvapay client
+// implements it.
+type Confirmer interface {
+	GetTransaction(ctx context.Context, id string) (*qvapay.TransactionReponse, error)
+}
+
+// EventHandler processes a payment event, returning an error makes the
+// handler answer with an error status so QvaPay delivers the callback again.
+type EventHandler interface {
+	HandleEvent(ctx context.Context, event PaymentEvent) error
+}
+
+// EventHandlerFunc is a function that implements EventHandler.
+type EventHandlerFunc func(ctx context.Context, event PaymentEvent) error
+
+func (f EventHandlerFunc) HandleEvent(ctx context.Context, event PaymentEvent) error {
+	return f(ctx, event)
+}
+
+// Options configures a Handler.
+type Options struct {
+	// optional but needed to trust the events, confirms every event with
+	// GetTransaction before dispatching it, the API amount, remote_id and
+	// status are then authoritative
+	Confirmer Confirmer
+	// optional, defaults to DefaultMaxBodySize
+	MaxBodySize int64
+	// optional, receives the errors answered to QvaPay
+	ErrorHandler func(error)
+	// optional, delivers every state change of a transaction once and keeps
+	// the failed events for RetryFailed and Replay
+	Store EventStore
+}
+
+// ErrNoStore is returned by Replay and RetryFailed without Options.Store.
+var ErrNoStore = errors.New("webhook: no event store")
+
+// Handler is the http.Handler of the app callback. It answers:
+//   - 200 once every event handler processed the event
+//   - 400 for payloads that can't be parsed or don't match the transaction
+//   - 405 for methods other than GET and POST
+//   - 500 when an event handler fails, 502 when the confirmation fails and
+//     503 when the transaction isn't settled, so QvaPay delivers the
+//     callback again
+type Handler struct {
+	opts     Options
+	mu       sync.RWMutex
+	handlers []EventHandler
+}
+
+// NewHandler returns a Handler that dispatches to handlers.
+func NewHandler(opts Options, handlers ...EventHandler) *Handler {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	return &Handler{opts: opts, handlers: handlers}
+}
+
+// Handle registers an event handler, handlers run in registration order.
+func (h *Handler) Handle(handler EventHandler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handlers = append(h.handlers, handler)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET, POST")
+		h.fail(w, http.StatusMethodNotAllowed, fmt.Errorf("webhook: method %s not allowed", r.Method))
+		return
+	}
+	event, err := ParseRequest(r, h.opts.MaxBodySize)
+	if err != nil {
+		h.fail(w, http.StatusBadRequest, err)
+		return
+	}
+	if h.opts.Confirmer != nil {
+		if event, err = h.confirm(r.Context(), event); err != nil {
+			status := http.StatusBadGateway
+			switch {
+			case errors.Is(err, ErrMismatch) || errors.Is(err, qvapay.ErrNotFound):
+				status = http.StatusBadRequest
+			case errors.Is(err, ErrNotSettled):
+				status = http.StatusServiceUnavailable
+			}
+			h.fail(w, status, err)
+			return
+		}
+	}
+	if err := h.process(r.Context(), event); err != nil {
+		h.fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true})
+}
+
+// confirm checks the event against the transaction returned by the API. A
+// transaction without a final status in the API isn't settled, whatever the
+// callback says, the callback is answered with an error and comes back
+// later. A final status of the API wins over the status of the callback,
+// it can't change anymore.
+func (h *Handler) confirm(ctx context.Context, event PaymentEvent) (PaymentEvent, error) {
+	tx, err := h.opts.Confirmer.GetTransaction(ctx, event.TransactionUUID)
+	if err != nil {
+		return event, fmt.Errorf("webhook: confirming transaction %s: %w", event.TransactionUUID, err)
+	}
+	if tx.ID != "" && tx.ID != event.TransactionUUID {
+		return event, fmt.Errorf("%w: uuid %s, got %s", ErrMismatch, event.TransactionUUID, tx.ID)
+	}
+	if event.RemoteID != "" && tx.RemoteID != event.RemoteID {
+		return event, fmt.Errorf("%w: remote_id %s, got %s", ErrMismatch, event.RemoteID, tx.RemoteID)
+	}
+	if !event.Amount.IsZero() && !tx.Amount.Equal(event.Amount) {
+		return event, fmt.Errorf("%w: amount %s, got %s", ErrMismatch, event.Amount, tx.Amount)
+	}
+	if !tx.Status.IsFinal() {
+		return event, fmt.Errorf("%w: %s is %s", ErrNotSettled, event.TransactionUUID, tx.Status)
+	}
+	event.RemoteID = tx.RemoteID
+	event.Amount = tx.Amount
+	event.Status = tx.Status
+	event.Confirmed = true
+	return event, nil
+}
+
+// process delivers the event, once per state change when there is a store.
+// Events older than a state change already processed are dropped, they
+// arrived out of order.
+func (h *Handler) process(ctx context.Context, event PaymentEvent) error {
+	if h.opts.Store == nil {
+		return h.dispatch(ctx, event)
+	}
+	records, err := h.opts.Store.Records(ctx, RecordFilter{
+		TransactionUUID: event.TransactionUUID,
+		State:           StateProcessed,
+	})
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		var transitionErr *qvapay.TransitionError
+		if errors.As(qvapay.ValidateTransition(r.Event.Status, event.Status), &transitionErr) && transitionErr.Backwards {
+			return nil
+		}
+	}
+	return h.deliver(ctx, event)
+}
+
+// deliver claims the event in the store and dispatches it.
+func (h *Handler) deliver(ctx context.Context, event PaymentEvent) error {
+	claimed, err := h.opts.Store.Claim(ctx, event)
+	if err != nil || !claimed {
+		return err
+	}
+	if err := h.dispatch(ctx, event); err != nil {
+		return errors.Join(err, h.opts.Store.Fail(ctx, event.Key(), err))
+	}
+	return h.opts.Store.Complete(ctx, event.Key())
+}
+
+// Replay dispatches again the stored events matching filter, whatever
+// their state, e.g. after fixing a bug in an event handler.
+func (h *Handler) Replay(ctx context.Context, filter RecordFilter) error {
+	if h.opts.Store == nil {
+		return ErrNoStore
+	}
+	records, err := h.opts.Store.Records(ctx, filter)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, r := range records {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		if err := h.opts.Store.Release(ctx, r.Event.Key()); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := h.deliver(ctx, r.Event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RetryFailed dispatches again the events whose handlers failed.
+func (h *Handler) RetryFailed(ctx context.Context) error {
+	return h.Replay(ctx, RecordFilter{State: StateFailed})
+}
+
+func (h *Handler) dispatch(ctx context.Context, event PaymentEvent) error {
+	h.mu.RLock()
+	handlers := h.handlers
+	h.mu.RUnlock()
+	for _, handler := range handlers {
+		if err := handler.HandleEvent(ctx, event); err != nil {
+			return fmt.Errorf("webhook: handling transaction %s: %w", event.TransactionUUID, err)
+		}
+	}
+	return nil
+}
+
+func (h *Handler) fail(w http.ResponseWriter, status int, err error) {
+	if h.opts.ErrorHandler != nil {
+		h.opts.ErrorHandler(err)
+	}
+	writeJSON(w, status, map[string]any{"error": http.StatusText(status)})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,118 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/kenriortega/qvapay-go/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(h http.Handler, r *http.Request) int {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code
+}
+
+func Test_Handler_Parses_Callbacks(t *testing.T) {
+	var events []webhook.PaymentEvent
+	h := webhook.NewHandler(webhook.Options{}, webhook.EventHandlerFunc(
+		func(_ context.Context, e webhook.PaymentEvent) error {
+			events = append(events, e)
+			return nil
+		},
+	))
+
+	r := httptest.NewRequest(http.MethodPost, "/webhook",
+		strings.NewReader(`{"transaction_uuid":"6507ee0d","remote_id":"BRID56568989","amount":25.60,"status":"paid"}`))
+	r.Header.Set("Content-Type", "application/json")
+	assert.Equal(t, http.StatusOK, serve(h, r))
+
+	form := url.Values{"uuid": {"543105f4"}, "remote_id": {"15803"}, "amount": {"30.00"}}
+	r = httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	assert.Equal(t, http.StatusOK, serve(h, r))
+
+	assert.Equal(t, []webhook.PaymentEvent{
+		{TransactionUUID: "6507ee0d", RemoteID: "BRID56568989", Amount: qvapay.MustParseAmount("25.60"), Status: qvapay.StatusPaid},
+		{TransactionUUID: "543105f4", RemoteID: "15803", Amount: qvapay.MustParseAmount("30")},
+	}, events)
+}
+
+func Test_Handler_Status_Codes(t *testing.T) {
+	failing := webhook.NewHandler(webhook.Options{}, webhook.EventHandlerFunc(
+		func(context.Context, webhook.PaymentEvent) error { return errors.New("db down") },
+	))
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(failing, httptest.NewRequest(http.MethodDelete, "/webhook", nil)))
+	assert.Equal(t, http.StatusBadRequest, serve(failing, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"remote_id":"1"}`))))
+	assert.Equal(t, http.StatusBadRequest, serve(failing, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"uuid":"1","amount":"abc"}`))))
+	assert.Equal(t, http.StatusInternalServerError, serve(failing, httptest.NewRequest(http.MethodGet, "/webhook?uuid=1", nil)))
+}
+
+func Test_Handler_Confirms_Events(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasSuffix(r.URL.Path, "/6507ee0d") {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{"uuid":"6507ee0d","remote_id":"15803","amount":"30.00","status":"paid"}`))
+		}),
+	)
+	defer s.Close()
+
+	var confirmed []webhook.PaymentEvent
+	h := webhook.NewHandler(
+		webhook.Options{Confirmer: qvapay.NewPaymentAppClient(qvapay.Options{BaseURL: s.URL})},
+		webhook.EventHandlerFunc(func(_ context.Context, e webhook.PaymentEvent) error {
+			confirmed = append(confirmed, e)
+			return nil
+		}),
+	)
+
+	assert.Equal(t, http.StatusOK, serve(h, httptest.NewRequest(http.MethodGet, "/webhook?uuid=6507ee0d&status=pending", nil)))
+	assert.Equal(t, http.StatusBadRequest, serve(h, httptest.NewRequest(http.MethodGet, "/webhook?uuid=6507ee0d&amount=1000", nil)))
+	assert.Equal(t, http.StatusBadRequest, serve(h, httptest.NewRequest(http.MethodGet, "/webhook?uuid=unknown", nil)))
+
+	if assert.Len(t, confirmed, 1) {
+		assert.True(t, confirmed[0].Confirmed)
+		assert.Equal(t, qvapay.StatusPaid, confirmed[0].Status)
+		assert.Equal(t, "15803", confirmed[0].RemoteID)
+	}
+}
+
+func Test_Handler_Retries_Unsettled_Events(t *testing.T) {
+	status := "pending"
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"uuid":"6507ee0d","remote_id":"15803","amount":"30.00","status":"` + status + `"}`))
+		}),
+	)
+	defer s.Close()
+
+	var confirmed []webhook.PaymentEvent
+	h := webhook.NewHandler(
+		webhook.Options{Confirmer: qvapay.NewPaymentAppClient(qvapay.Options{BaseURL: s.URL})},
+		webhook.EventHandlerFunc(func(_ context.Context, e webhook.PaymentEvent) error {
+			confirmed = append(confirmed, e)
+			return nil
+		}),
+	)
+
+	for _, query := range []string{"uuid=6507ee0d&status=paid", "uuid=6507ee0d"} {
+		assert.Equal(t, http.StatusServiceUnavailable, serve(h, httptest.NewRequest(http.MethodGet, "/webhook?"+query, nil)), query)
+	}
+	assert.Empty(t, confirmed)
+
+	status = "paid"
+	assert.Equal(t, http.StatusOK, serve(h, httptest.NewRequest(http.MethodGet, "/webhook?uuid=6507ee0d&status=paid", nil)))
+	if assert.Len(t, confirmed, 1) {
+		assert.Equal(t, qvapay.StatusPaid, confirmed[0].Status)
+	}
+}
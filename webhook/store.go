@@ -0,0 +1,242 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kenriortega/qvapay-go"
+)
+
+// RecordState is the processing state of an event in an EventStore.
+type RecordState string
+
+const (
+	StateProcessing RecordState = "processing"
+	StateProcessed  RecordState = "processed"
+	StateFailed     RecordState = "failed"
+)
+
+// EventKey identifies a state change of a transaction, every key is
+// delivered once to the handlers.
+type EventKey struct {
+	TransactionUUID string                   `json:"transaction_uuid"`
+	Status          qvapay.TransactionStatus `json:"status"`
+}
+
+// Key returns the key of the event.
+func (e PaymentEvent) Key() EventKey {
+	return EventKey{TransactionUUID: e.TransactionUUID, Status: e.Status}
+}
+
+// Record is an event stored in an EventStore.
+type Record struct {
+	Event     PaymentEvent `json:"event"`
+	State     RecordState  `json:"state"`
+	Attempts  int          `json:"attempts"`
+	LastError string       `json:"last_error,omitempty"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// RecordFilter selects records, empty fields match every record.
+type RecordFilter struct {
+	TransactionUUID string
+	State           RecordState
+}
+
+func (f RecordFilter) match(r Record) bool {
+	return (f.TransactionUUID == "" || f.TransactionUUID == r.Event.TransactionUUID) &&
+		(f.State == "" || f.State == r.State)
+}
+
+// EventStore records the processed events so the Handler delivers every
+// state change once, and keeps the failed ones for a later retry.
+type EventStore interface {
+	// Claim marks the event as processing, it returns false when the event
+	// is already processed or being processed.
+	Claim(ctx context.Context, event PaymentEvent) (bool, error)
+	// Complete marks a claimed event as processed.
+	Complete(ctx context.Context, key EventKey) error
+	// Fail marks a claimed event as failed, it can be claimed again.
+	Fail(ctx context.Context, key EventKey, cause error) error
+	// Release marks an event as failed whatever its state, so it can be
+	// claimed again, it's used to replay events.
+	Release(ctx context.Context, key EventKey) error
+	// Records returns the records matching filter, oldest first.
+	Records(ctx context.Context, filter RecordFilter) ([]Record, error)
+}
+
+// ErrUnknownEvent is returned for keys that are not in the store.
+var ErrUnknownEvent = errors.New("webhook: unknown event")
+
+// MemoryStore is an EventStore that lives in memory, its records are lost
+// when the process ends.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[EventKey]*Record
+	now     func() time.Time
+	// persist is called with the lock held after every change.
+	persist func() error
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: map[EventKey]*Record{}, now: time.Now}
+}
+
+func (s *MemoryStore) Claim(_ context.Context, event PaymentEvent) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.records[event.Key()]
+	if ok && prev.State != StateFailed {
+		return false, nil
+	}
+	r := Record{}
+	if ok {
+		r = *prev
+	}
+	r.Event = event
+	r.State = StateProcessing
+	r.Attempts++
+	r.UpdatedAt = s.now()
+	// a claim that isn't saved is undone, otherwise the redelivery of the
+	// event would find it processing and drop it
+	s.records[event.Key()] = &r
+	if err := s.save(); err != nil {
+		if ok {
+			s.records[event.Key()] = prev
+		} else {
+			delete(s.records, event.Key())
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, key EventKey) error {
+	return s.update(key, func(r *Record) {
+		r.State = StateProcessed
+		r.LastError = ""
+	})
+}
+
+func (s *MemoryStore) Fail(_ context.Context, key EventKey, cause error) error {
+	return s.update(key, func(r *Record) {
+		r.State = StateFailed
+		if cause != nil {
+			r.LastError = cause.Error()
+		}
+	})
+}
+
+func (s *MemoryStore) Release(_ context.Context, key EventKey) error {
+	return s.update(key, func(r *Record) {
+		r.State = StateFailed
+	})
+}
+
+func (s *MemoryStore) update(key EventKey, fn func(r *Record)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.records[key]
+	if !ok {
+		return fmt.Errorf("%w: %s %s", ErrUnknownEvent, key.TransactionUUID, key.Status)
+	}
+	fn(r)
+	r.UpdatedAt = s.now()
+	return s.save()
+}
+
+func (s *MemoryStore) Records(_ context.Context, filter RecordFilter) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list(filter), nil
+}
+
+func (s *MemoryStore) list(filter RecordFilter) []Record {
+	records := []Record{}
+	for _, r := range s.records {
+		if filter.match(*r) {
+			records = append(records, *r)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].UpdatedAt.Before(records[j].UpdatedAt)
+	})
+	return records
+}
+
+func (s *MemoryStore) save() error {
+	if s.persist == nil {
+		return nil
+	}
+	return s.persist()
+}
+
+// FileStore is an EventStore persisted in a JSON file, the whole file is
+// rewritten atomically on every change. It suits low traffic apps, use a
+// database backed EventStore otherwise.
+type FileStore struct {
+	*MemoryStore
+	path string
+}
+
+// OpenFileStore loads the store from path, creating it if needed. Events
+// left processing by a previous process are marked as failed.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{MemoryStore: NewMemoryStore(), path: path}
+	data, err := os.ReadFile(filepath.Clean(path))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("webhook: opening store: %w", err)
+	default:
+		var records []Record
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("webhook: decoding store %s: %w", path, err)
+		}
+		for i := range records {
+			r := records[i]
+			if r.State == StateProcessing {
+				r.State = StateFailed
+				r.LastError = "interrupted while processing"
+			}
+			s.records[r.Event.Key()] = &r
+		}
+	}
+	s.persist = s.write
+	return s, nil
+}
+
+func (s *FileStore) write() error {
+	data, err := json.MarshalIndent(s.list(RecordFilter{}), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("webhook: writing store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("webhook: writing store: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("webhook: writing store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("webhook: writing store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("webhook: writing store: %w", err)
+	}
+	return nil
+}
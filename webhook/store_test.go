@@ -0,0 +1,135 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kenriortega/qvapay-go"
+	"github.com/kenriortega/qvapay-go/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func callback(query string) *http.Request {
+	return httptest.NewRequest(http.MethodGet, "/webhook?"+query, nil)
+}
+
+func Test_Store_Delivers_Each_State_Change_Once(t *testing.T) {
+	var delivered []qvapay.TransactionStatus
+	h := webhook.NewHandler(
+		webhook.Options{Store: webhook.NewMemoryStore()},
+		webhook.EventHandlerFunc(func(_ context.Context, e webhook.PaymentEvent) error {
+			delivered = append(delivered, e.Status)
+			return nil
+		}),
+	)
+	for _, query := range []string{
+		"uuid=1&status=pending",
+		"uuid=1&status=paid",
+		"uuid=1&status=paid",
+		"uuid=1&status=pending",
+	} {
+		assert.Equal(t, http.StatusOK, serve(h, callback(query)), query)
+	}
+	assert.Equal(t, []qvapay.TransactionStatus{qvapay.StatusPending, qvapay.StatusPaid}, delivered)
+
+	// out of order: paid arrives before pending
+	delivered = nil
+	serve(h, callback("uuid=2&status=paid"))
+	serve(h, callback("uuid=2&status=pending"))
+	assert.Equal(t, []qvapay.TransactionStatus{qvapay.StatusPaid}, delivered)
+}
+
+func Test_Store_Retries_Failed_Events(t *testing.T) {
+	store := webhook.NewMemoryStore()
+	failing := true
+	credited := 0
+	h := webhook.NewHandler(
+		webhook.Options{Store: store},
+		webhook.EventHandlerFunc(func(context.Context, webhook.PaymentEvent) error {
+			if failing {
+				return errors.New("db down")
+			}
+			credited++
+			return nil
+		}),
+	)
+	assert.Equal(t, http.StatusInternalServerError, serve(h, callback("uuid=1")))
+
+	records, err := store.Records(context.Background(), webhook.RecordFilter{State: webhook.StateFailed})
+	assert.NoError(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Contains(t, records[0].LastError, "db down")
+	}
+
+	failing = false
+	assert.NoError(t, h.RetryFailed(context.Background()))
+	assert.Equal(t, http.StatusOK, serve(h, callback("uuid=1")))
+	assert.Equal(t, 1, credited)
+}
+
+func Test_File_Store_Persists_And_Replays(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+	store, err := webhook.OpenFileStore(path)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	calls := 0
+	handler := webhook.EventHandlerFunc(func(context.Context, webhook.PaymentEvent) error {
+		calls++
+		return nil
+	})
+	h := webhook.NewHandler(webhook.Options{Store: store}, handler)
+	assert.Equal(t, http.StatusOK, serve(h, callback("uuid=1&amount=25.60")))
+
+	reopened, err := webhook.OpenFileStore(path)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	h = webhook.NewHandler(webhook.Options{Store: reopened}, handler)
+	assert.Equal(t, http.StatusOK, serve(h, callback("uuid=1&amount=25.60")))
+	assert.Equal(t, 1, calls)
+
+	assert.NoError(t, h.Replay(context.Background(), webhook.RecordFilter{TransactionUUID: "1"}))
+	assert.Equal(t, 2, calls)
+
+	records, err := reopened.Records(context.Background(), webhook.RecordFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, webhook.StateProcessed, records[0].State)
+		assert.Equal(t, 2, records[0].Attempts)
+		assert.Equal(t, qvapay.MustParseAmount("25.60"), records[0].Event.Amount)
+	}
+}
+
+func Test_File_Store_Write_Failure_Keeps_The_Event(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "events")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatalf(err.Error())
+	}
+	store, err := webhook.OpenFileStore(filepath.Join(dir, "events.json"))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	calls := 0
+	h := webhook.NewHandler(webhook.Options{Store: store}, webhook.EventHandlerFunc(
+		func(context.Context, webhook.PaymentEvent) error {
+			calls++
+			return nil
+		},
+	))
+
+	assert.NoError(t, os.RemoveAll(dir))
+	assert.Equal(t, http.StatusInternalServerError, serve(h, callback("uuid=1&status=paid")))
+	assert.Equal(t, http.StatusInternalServerError, serve(h, callback("uuid=1&status=paid")))
+	assert.Equal(t, 0, calls)
+
+	assert.NoError(t, os.Mkdir(dir, 0o700))
+	assert.Equal(t, http.StatusOK, serve(h, callback("uuid=1&status=paid")))
+	assert.Equal(t, http.StatusOK, serve(h, callback("uuid=1&status=paid")))
+	assert.Equal(t, 1, calls)
+}